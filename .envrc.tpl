@@ -1,2 +1,4 @@
-export GITLAB_ACCESS_TOKEN=your_gitlab_access_token
-export PUSHGATEWAY_URL=http://localhost:9091
\ No newline at end of file
+export GITLAB_SCRAPER_ACCESS_TOKEN=your_gitlab_access_token
+export GITLAB_SCRAPER_PUSH_GATEWAY_URL=http://localhost:9091
+# Optional, defaults to https://gitlab.com
+export GITLAB_SCRAPER_GITLAB_URL=https://gitlab.com
@@ -0,0 +1,60 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	aggregateSum = "sum"
+	aggregateAvg = "avg"
+	aggregateMax = "max"
+	aggregateMin = "min"
+)
+
+var aggregationFuncs = []string{aggregateSum, aggregateAvg, aggregateMax, aggregateMin}
+
+// aggregate combines the values of the projects of a group into a single
+// group value. An empty fn sums up the values.
+func aggregate(values []float64, fn string) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	switch fn {
+	case aggregateAvg:
+		return aggregate(values, aggregateSum) / float64(len(values))
+	case aggregateMax:
+		return slices.Max(values)
+	case aggregateMin:
+		return slices.Min(values)
+	default:
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+		return sum
+	}
+}
+
+// validateAggregations checks the aggregation_func of every group metric
+// that aggregates project values.
+func validateAggregations(groups []GroupConfig) error {
+	for _, group := range groups {
+		var funcs []string
+		if group.GroupStatistics != nil {
+			funcs = append(funcs, group.GroupStatistics.AggregationFunc)
+		}
+		if group.PipelineAvgDuration != nil {
+			funcs = append(funcs, group.PipelineAvgDuration.AggregationFunc)
+		}
+		for _, fn := range funcs {
+			if fn != "" && !slices.Contains(aggregationFuncs, fn) {
+				return fmt.Errorf("group %s: unknown aggregation_func %q, expected one of %v", group.ID, fn, aggregationFuncs)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// alertKey identifies a series of gitlab_project_alert_count.
+type alertKey struct {
+	Status   string
+	Severity string
+}
+
+type AlertCount struct {
+	Counts map[alertKey]int
+	// Available is false for projects without alert management.
+	Available bool
+}
+
+const alertsQuery = `query($fullPath: ID!, $statuses: [AlertManagementStatus!], $after: String) {
+  project(fullPath: $fullPath) {
+    alertManagementAlerts(statuses: $statuses, first: 100, after: $after) {
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      nodes {
+        status
+        severity
+      }
+    }
+  }
+}`
+
+type alertsResponse struct {
+	Project *struct {
+		Alerts *struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				Status   string `json:"status"`
+				Severity string `json:"severity"`
+			} `json:"nodes"`
+		} `json:"alertManagementAlerts"`
+	} `json:"project"`
+}
+
+// getAlertCount counts the alerts of the project by status and severity,
+// only those with the configured status if set. The REST API does not list
+// alerts, so they are read through GraphQL.
+func getAlertCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (AlertCount, error) {
+	fullPath, err := projectFullPath(ctx, git, project.ID)
+	if err != nil {
+		return AlertCount{}, err
+	}
+
+	variables := map[string]any{"fullPath": fullPath}
+	if status := project.AlertCount.Status; status != "" {
+		variables["statuses"] = []string{strings.ToUpper(status)}
+	}
+
+	count := AlertCount{Counts: map[alertKey]int{}, Available: true}
+	for {
+		var alerts alertsResponse
+		ok, err := doGraphQL(ctx, git, alertsQuery, variables, &alerts)
+		if err != nil {
+			return AlertCount{}, fmt.Errorf("failed to list alerts for project %s: %w", project.ID, err)
+		}
+
+		result := alerts.Project
+		if !ok || result == nil || result.Alerts == nil {
+			return AlertCount{}, nil
+		}
+
+		for _, alert := range result.Alerts.Nodes {
+			count.Counts[alertKey{Status: strings.ToLower(alert.Status), Severity: strings.ToLower(alert.Severity)}]++
+		}
+
+		if !result.Alerts.PageInfo.HasNextPage {
+			return count, nil
+		}
+		variables["after"] = result.Alerts.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+var (
+	logAPIResponses     bool
+	logResponseMaxBytes int
+)
+
+// sensitiveFieldSuffixes and sensitiveFieldInfixes match the fields that
+// are redacted from logged API responses wherever they appear in the JSON
+// document, e.g. runners_token, webhook_secret or ssh_key_fingerprint.
+var (
+	sensitiveFieldSuffixes = []string{"token", "secret", "password"}
+	sensitiveFieldInfixes  = []string{"_key"}
+)
+
+const redacted = "[REDACTED]"
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&logAPIResponses, "log-api-responses", false, "Log the JSON body of every successful GitLab API response at debug level")
+	scrapeCmd.Flags().IntVar(&logResponseMaxBytes, "log-response-max-bytes", 2048, "Truncate logged API responses to this many bytes")
+}
+
+// enableAPIResponseLogging makes debug messages visible, since the default
+// logger only shows info and above.
+func enableAPIResponseLogging() {
+	slog.SetDefault(slog.New(newLogHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
+// responseLogger logs the bodies of successful JSON responses and hands an
+// unread copy of the body on to the caller.
+type responseLogger struct {
+	next     http.RoundTripper
+	maxBytes int
+}
+
+func (l *responseLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := l.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, err
+	}
+	// Archives and artifacts can be large and are not interesting to log.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	logged := redactJSON(body)
+	truncated := len(logged) > l.maxBytes
+	if truncated {
+		logged = logged[:l.maxBytes]
+	}
+	slog.Debug("GitLab API response", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode,
+		"bytes", len(body), "truncated", truncated, "body", string(logged))
+	return resp, nil
+}
+
+// redactJSON replaces the values of sensitive fields in body. Bodies that
+// are not valid JSON are dropped entirely rather than logged unredacted.
+func redactJSON(body []byte) []byte {
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return []byte("<invalid JSON>")
+	}
+	out, err := json.Marshal(redactValue(document))
+	if err != nil {
+		return []byte("<invalid JSON>")
+	}
+	return out
+}
+
+func isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+	return slices.ContainsFunc(sensitiveFieldSuffixes, func(suffix string) bool { return strings.HasSuffix(key, suffix) }) ||
+		slices.ContainsFunc(sensitiveFieldInfixes, func(infix string) bool { return strings.Contains(key, infix) })
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if isSensitiveField(key) {
+				value[key] = redacted
+				continue
+			}
+			value[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+	}
+	return value
+}
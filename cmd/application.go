@@ -0,0 +1,70 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// getApplicationStats calls the admin only /application/statistics endpoint,
+// which reports instance wide counts as delimited number strings such as
+// "1,234". ok is false when the token is not allowed to read them.
+func getApplicationStats(ctx context.Context, git *gitlab.Client) (stats map[string]float64, ok bool, err error) {
+	req, err := git.NewRequest(http.MethodGet, "application/statistics", nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create application statistics request: %w", err)
+	}
+
+	var raw map[string]string
+	resp, err := git.Do(req, &raw)
+	if isFeatureUnavailable(resp, err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get application statistics: %w", err)
+	}
+
+	stats = map[string]float64{}
+	for field, value := range raw {
+		number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", ""), 64)
+		if err != nil {
+			fmt.Printf("Skipping non-numeric application statistic %s: %q\n", field, value)
+			continue
+		}
+		stats[field] = number
+	}
+	return stats, true, nil
+}
+
+func collectApplicationStats(ctx context.Context, git *gitlab.Client, labels prometheus.Labels) ([]prometheus.Collector, error) {
+	stats, ok, err := getApplicationStats(ctx, git)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		fmt.Println("Application statistics are not available, an administrator token is required")
+		return []prometheus.Collector{featureUnavailableGauge("application_statistics", labels)}, nil
+	}
+
+	fields := slices.Sorted(maps.Keys(stats))
+	var collectors []prometheus.Collector
+	for _, field := range fields {
+		name := "gitlab_application_" + field
+		if !matchesMetricsFilter([]string{name}) {
+			continue
+		}
+		fmt.Printf("Application statistic %s: %v\n", field, stats[field])
+		collectors = append(collectors, newGauge(name, fmt.Sprintf("Number of %s on the GitLab instance", strings.ReplaceAll(field, "_", " ")), labels, stats[field]))
+	}
+	return collectors, nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const defaultArtifactCountMaxPipelines = 20
+
+func (c *ArtifactCountConfig) maxPipelines() int {
+	if c.MaxPipelines <= 0 {
+		return defaultArtifactCountMaxPipelines
+	}
+	return c.MaxPipelines
+}
+
+type ArtifactCount struct {
+	Files int
+	// Expired counts the jobs whose artifacts expired but are still listed,
+	// only if include_expired is set.
+	Expired int
+}
+
+// getArtifactCount counts the jobs with an artifacts archive in the most
+// recent pipelines of the project. Every pipeline costs an additional API
+// call, so no more than max_pipelines pipelines are inspected.
+func getArtifactCount(ctx context.Context, git *gitlab.Client, project ProjectConfig, now time.Time) (ArtifactCount, error) {
+	config := project.CIJobArtifactCount
+	remaining := config.maxPipelines()
+	options := &gitlab.ListProjectPipelinesOptions{
+		OrderBy: gitlab.Ptr("id"),
+		Sort:    gitlab.Ptr("desc"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: min(remaining, 100),
+		},
+	}
+
+	var pipelines []*gitlab.PipelineInfo
+	for remaining > 0 {
+		page, resp, err := git.Pipelines.ListProjectPipelines(project.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return ArtifactCount{}, fmt.Errorf("failed to list pipelines for project %s: %w", project.ID, err)
+		}
+		page = page[:min(len(page), remaining)]
+		pipelines = append(pipelines, page...)
+		remaining -= len(page)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	var count ArtifactCount
+	for _, pipeline := range pipelines {
+		jobOptions := &gitlab.ListJobsOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+
+		for {
+			jobs, resp, err := git.Jobs.ListPipelineJobs(project.ID, pipeline.ID, jobOptions, gitlab.WithContext(ctx))
+			if err != nil {
+				return ArtifactCount{}, fmt.Errorf("failed to list jobs of pipeline %d for project %s: %w", pipeline.ID, project.ID, err)
+			}
+
+			for _, job := range jobs {
+				if job.ArtifactsFile.Filename == "" || (config.JobName != "" && job.Name != config.JobName) {
+					continue
+				}
+				if job.ArtifactsExpireAt != nil && job.ArtifactsExpireAt.Before(now) {
+					if config.IncludeExpired {
+						count.Expired++
+					}
+					continue
+				}
+				count.Files++
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			jobOptions.Page = resp.NextPage
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type BadgeCount struct {
+	Total int
+	// GroupInherited counts the badges the project shows because they are
+	// set on one of its groups.
+	GroupInherited int
+}
+
+// getBadgeCount counts the badges of the project, which include the badges
+// of its groups.
+func getBadgeCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (BadgeCount, error) {
+	opt := &gitlab.ListProjectBadgesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var count BadgeCount
+	for {
+		badges, resp, err := git.ProjectBadges.ListProjectBadges(project.ID, opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return BadgeCount{}, fmt.Errorf("failed to list badges for project %s: %w", project.ID, err)
+		}
+
+		for _, badge := range badges {
+			count.Total++
+			if badge.Kind == "group" {
+				count.GroupInherited++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
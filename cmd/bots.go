@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type BotMemberCount struct {
+	Bots   int
+	Humans int
+}
+
+// botMember is a group member as returned by the members API. The client
+// does not decode the bot field, which newer GitLab versions add for bot
+// users such as the ones behind group and project access tokens.
+type botMember struct {
+	ID  int   `json:"id"`
+	Bot *bool `json:"bot"`
+}
+
+// getBotMemberCount counts the bot and human members of the group, including
+// inherited members. Versions of GitLab that do not report the bot field
+// have all members counted as human.
+func getBotMemberCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (BotMemberCount, error) {
+	u := fmt.Sprintf("groups/%s/members/all", gitlab.PathEscape(group.ID))
+	opt := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var count BotMemberCount
+	for {
+		req, err := git.NewRequest(http.MethodGet, u, opt, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return BotMemberCount{}, fmt.Errorf("failed to create member list request for group %s: %w", group.ID, err)
+		}
+
+		var members []botMember
+		resp, err := git.Do(req, &members)
+		if err != nil {
+			return BotMemberCount{}, fmt.Errorf("failed to list members for group %s: %w", group.ID, err)
+		}
+
+		for _, member := range members {
+			if member.Bot != nil && *member.Bot {
+				count.Bots++
+			} else {
+				count.Humans++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"container/heap"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func (c CardinalityConfig) enabled() bool {
+	return c.MaxTimeSeriesTotal > 0 || len(c.MaxLabelValues) > 0
+}
+
+func validateCardinality(config CardinalityConfig) error {
+	if config.MaxTimeSeriesTotal < 0 {
+		return fmt.Errorf("metric_cardinality max_time_series_total must not be negative, got %d", config.MaxTimeSeriesTotal)
+	}
+	for label, limit := range config.MaxLabelValues {
+		if limit <= 0 {
+			return fmt.Errorf("metric_cardinality max_label_values of %s must be positive, got %d", label, limit)
+		}
+	}
+	return nil
+}
+
+// seriesUnit is the part of a metric family pushed for one group, which is
+// dropped as a whole when the series limit is exceeded.
+type seriesUnit struct {
+	family  *dto.MetricFamily
+	groupID string
+	weight  float64
+	metrics []*dto.Metric
+}
+
+// seriesQueue is a heap of series units ordered by the weight of their
+// group, lowest first, and then by metric name and group.
+type seriesQueue []*seriesUnit
+
+func (q seriesQueue) Len() int { return len(q) }
+
+func (q seriesQueue) Less(i, j int) bool {
+	return cmp.Or(
+		cmp.Compare(q[i].weight, q[j].weight),
+		cmp.Compare(q[i].family.GetName(), q[j].family.GetName()),
+		cmp.Compare(q[i].groupID, q[j].groupID),
+	) < 0
+}
+
+func (q seriesQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *seriesQueue) Push(x any) { *q = append(*q, x.(*seriesUnit)) }
+
+func (q *seriesQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// enforceCardinality drops series beyond the limits of config and reports
+// how many it dropped in gitlab_scrape_series_dropped_total. Series with a
+// label value beyond the first max_label_values distinct values of that
+// label are dropped first. Then, while there are more than
+// max_time_series_total series, the metrics of the group with the lowest
+// weight are dropped, metric by metric. Series that do not belong to a
+// group have the default weight.
+func enforceCardinality(config *Config) metricTransform {
+	limits := config.MetricCardinality
+	weights := map[string]float64{}
+	for _, group := range config.Groups {
+		weights[group.ID] = group.weight()
+	}
+
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		dropped := 0
+		if len(limits.MaxLabelValues) > 0 {
+			seen := map[string]map[string]bool{}
+			for _, family := range families {
+				before := len(family.Metric)
+				family.Metric = slices.DeleteFunc(family.Metric, func(metric *dto.Metric) bool {
+					for _, label := range metric.GetLabel() {
+						limit, ok := limits.MaxLabelValues[label.GetName()]
+						if !ok {
+							continue
+						}
+						if seen[label.GetName()] == nil {
+							seen[label.GetName()] = map[string]bool{}
+						}
+						values := seen[label.GetName()]
+						if !values[label.GetValue()] && len(values) >= limit {
+							return true
+						}
+						values[label.GetValue()] = true
+					}
+					return false
+				})
+				if removed := before - len(family.Metric); removed > 0 {
+					warnf("dropping %d series of %s exceeding max_label_values", removed, family.GetName())
+					dropped += removed
+				}
+			}
+		}
+
+		if limits.MaxTimeSeriesTotal > 0 {
+			var queue seriesQueue
+			total := 0
+			for _, family := range families {
+				units := map[string]*seriesUnit{}
+				for _, metric := range family.GetMetric() {
+					total++
+					groupID := ""
+					for _, label := range metric.GetLabel() {
+						if label.GetName() == "group_id" {
+							groupID = label.GetValue()
+						}
+					}
+					if units[groupID] == nil {
+						units[groupID] = &seriesUnit{family: family, groupID: groupID, weight: cmp.Or(weights[groupID], defaultGroupWeight)}
+					}
+					units[groupID].metrics = append(units[groupID].metrics, metric)
+				}
+				for _, groupID := range slices.Sorted(maps.Keys(units)) {
+					queue = append(queue, units[groupID])
+				}
+			}
+			heap.Init(&queue)
+
+			for total > limits.MaxTimeSeriesTotal && queue.Len() > 0 {
+				unit := heap.Pop(&queue).(*seriesUnit)
+				unit.family.Metric = slices.DeleteFunc(unit.family.Metric, func(metric *dto.Metric) bool {
+					return slices.Contains(unit.metrics, metric)
+				})
+				if unit.groupID != "" {
+					warnf("dropping %d series of %s of group %s, more than %d series were collected", len(unit.metrics), unit.family.GetName(), unit.groupID, limits.MaxTimeSeriesTotal)
+				} else {
+					warnf("dropping %d series of %s, more than %d series were collected", len(unit.metrics), unit.family.GetName(), limits.MaxTimeSeriesTotal)
+				}
+				total -= len(unit.metrics)
+				dropped += len(unit.metrics)
+			}
+			families = slices.DeleteFunc(families, func(family *dto.MetricFamily) bool { return len(family.Metric) == 0 })
+		}
+
+		counter := prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "gitlab_scrape_series_dropped_total",
+			Help:        "Number of series dropped because they exceeded the metric_cardinality limits",
+			ConstLabels: config.DefaultLabels,
+		})
+		counter.Add(float64(dropped))
+		droppedFamilies, err := gatherCollectors(counter)
+		if err != nil {
+			return nil, err
+		}
+		return append(families, droppedFamilies...), nil
+	}
+}
+
+// gatherCollectors gathers collectors outside of the scrape registry.
+func gatherCollectors(collectors ...prometheus.Collector) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+	return registry.Gather()
+}
@@ -0,0 +1,170 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func newGitLabClient(config *Config, accessToken string) (*gitlab.Client, error) {
+	clientOptions := []gitlab.ClientOptionFunc{
+		gitlab.WithCustomBackoff(retryAfterBackoff),
+		gitlab.WithCustomRetry(retryAfterCheck(config.MaxRetryAfterWait)),
+	}
+	if config.GitLabURL != "" {
+		clientOptions = append(clientOptions, gitlab.WithBaseURL(config.GitLabURL))
+	}
+
+	transport := newGitLabTransport(config)
+	if transport != http.DefaultTransport {
+		clientOptions = append(clientOptions, gitlab.WithHTTPClient(&http.Client{Transport: transport}))
+	}
+
+	switch config.TokenType {
+	case tokenTypeOAuth2:
+		return gitlab.NewOAuthClient(accessToken, clientOptions...)
+	case tokenTypeJob:
+		return gitlab.NewJobClient(accessToken, clientOptions...)
+	default:
+		return gitlab.NewClient(accessToken, clientOptions...)
+	}
+}
+
+// newGitLabTransport returns the transport of the GitLab client, which is
+// http.DefaultTransport unless the config or flags change it.
+func newGitLabTransport(config *Config) http.RoundTripper {
+	var transport http.RoundTripper = http.DefaultTransport
+	if skipSSLVerify || config.ConnectionPool != (ConnectionPoolConfig{}) {
+		pooled := http.DefaultTransport.(*http.Transport).Clone()
+		if skipSSLVerify {
+			warnf("TLS certificate verification for the GitLab API is disabled")
+			pooled.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		config.ConnectionPool.apply(pooled)
+		transport = pooled
+	}
+	if logAPIResponses {
+		enableAPIResponseLogging()
+		transport = &responseLogger{next: transport, maxBytes: logResponseMaxBytes}
+	}
+	return transport
+}
+
+// Token types select the header the access token is sent in: PRIVATE-TOKEN,
+// Authorization: Bearer or JOB-TOKEN.
+const (
+	tokenTypePrivate = "private"
+	tokenTypeOAuth2  = "oauth2"
+	tokenTypeJob     = "job"
+)
+
+var tokenTypes = []string{tokenTypePrivate, tokenTypeOAuth2, tokenTypeJob}
+
+func validateTokenType(tokenType string) error {
+	if tokenType != "" && !slices.Contains(tokenTypes, tokenType) {
+		return fmt.Errorf("unknown token_type %q, expected one of %s", tokenType, strings.Join(tokenTypes, ", "))
+	}
+	return nil
+}
+
+// resolveAccessToken returns the token to authenticate with. A job token
+// only exists for the duration of a CI/CD job and is therefore always taken
+// from CI_JOB_TOKEN rather than the usual token sources.
+func resolveAccessToken(config *Config) string {
+	if config.TokenType != tokenTypeJob {
+		return getRequiredValue("access_token",
+			"Please provide an access token using the --token flag or GITLAB_SCRAPER_ACCESS_TOKEN environment variable")
+	}
+	token := os.Getenv("CI_JOB_TOKEN")
+	if token == "" {
+		fatalf("token_type job requires the CI_JOB_TOKEN environment variable, which is only set in GitLab CI/CD jobs")
+	}
+	return token
+}
+
+// Go's defaults for the connection pool of http.DefaultTransport.
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = http.DefaultMaxIdleConnsPerHost
+	defaultMaxConnsPerHost     = 0
+)
+
+// apply sets the connection pool limits on transport. Unset limits keep
+// Go's defaults, so a scrape with a high --concurrency usually only
+// needs max_idle_conns_per_host, which allows 2 idle connections by default.
+func (c ConnectionPoolConfig) apply(transport *http.Transport) {
+	transport.MaxIdleConns = cmp.Or(c.MaxIdleConns, defaultMaxIdleConns)
+	transport.MaxIdleConnsPerHost = cmp.Or(c.MaxIdleConnsPerHost, defaultMaxIdleConnsPerHost)
+	transport.MaxConnsPerHost = cmp.Or(c.MaxConnsPerHost, defaultMaxConnsPerHost)
+}
+
+func insecureHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}
+
+// retryAfter returns the wait time requested by a 429 response through its
+// Retry-After header, if there is one. The header holds either seconds or
+// an HTTP date, a date in the past asks for no wait at all.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(time.Until(at), 0), true
+}
+
+// retryAfterBackoff sleeps exactly as long as GitLab asked for when rate
+// limited and falls back to exponential backoff for everything else.
+func retryAfterBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if wait, ok := retryAfter(resp); ok {
+		return wait
+	}
+	return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
+}
+
+// retryAfterCheck retries rate limited and server side failures like the
+// default GitLab client does, but gives up on a 429 whose Retry-After exceeds
+// maxWait instead of blocking the scrape for that long. A maxWait of zero
+// disables the limit.
+func retryAfterCheck(maxWait time.Duration) retryablehttp.CheckRetry {
+	return func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if err != nil {
+			return false, err
+		}
+		if wait, ok := retryAfter(resp); ok && maxWait > 0 && wait > maxWait {
+			return false, fmt.Errorf("rate limited by GitLab: Retry-After of %s exceeds max_retry_after_wait of %s", wait, maxWait)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return true, nil
+		}
+		return false, nil
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConnectionPoolIsApplied(t *testing.T) {
+	config := &Config{ConnectionPool: ConnectionPoolConfig{MaxIdleConns: 50, MaxIdleConnsPerHost: 20, MaxConnsPerHost: 30}}
+	if _, err := newGitLabClient(config, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, ok := newGitLabTransport(config).(*http.Transport)
+	if !ok {
+		t.Fatalf("transport is %T, want *http.Transport", newGitLabTransport(config))
+	}
+	if transport.MaxIdleConns != 50 || transport.MaxIdleConnsPerHost != 20 || transport.MaxConnsPerHost != 30 {
+		t.Errorf("transport has MaxIdleConns %d, MaxIdleConnsPerHost %d and MaxConnsPerHost %d, want 50, 20 and 30",
+			transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+}
+
+func TestConnectionPoolDefaults(t *testing.T) {
+	for name, pool := range map[string]ConnectionPoolConfig{
+		"unset":   {},
+		"partial": {MaxConnsPerHost: 30},
+	} {
+		t.Run(name, func(t *testing.T) {
+			transport, ok := newGitLabTransport(&Config{ConnectionPool: pool}).(*http.Transport)
+			if !ok {
+				t.Fatal("transport is not an *http.Transport")
+			}
+			if transport.MaxIdleConns != defaultMaxIdleConns {
+				t.Errorf("MaxIdleConns = %d, want Go's default %d", transport.MaxIdleConns, defaultMaxIdleConns)
+			}
+			// http.Transport treats 0 as http.DefaultMaxIdleConnsPerHost.
+			if idle := cmp.Or(transport.MaxIdleConnsPerHost, http.DefaultMaxIdleConnsPerHost); idle != defaultMaxIdleConnsPerHost {
+				t.Errorf("MaxIdleConnsPerHost = %d, want Go's default %d", idle, defaultMaxIdleConnsPerHost)
+			}
+			if pool.MaxConnsPerHost == 0 && transport.MaxConnsPerHost != defaultMaxConnsPerHost {
+				t.Errorf("MaxConnsPerHost = %d, want Go's default %d", transport.MaxConnsPerHost, defaultMaxConnsPerHost)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for name, test := range map[string]struct {
+		header   string
+		ok       bool
+		min, max time.Duration
+	}{
+		"seconds":     {header: "30", ok: true, min: 30 * time.Second, max: 30 * time.Second},
+		"http date":   {header: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), ok: true, min: 58 * time.Second, max: time.Minute},
+		"past date":   {header: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), ok: true},
+		"negative":    {header: "-1"},
+		"unparseable": {header: "soon"},
+		"missing":     {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+			if test.header != "" {
+				resp.Header.Set("Retry-After", test.header)
+			}
+			wait, ok := retryAfter(resp)
+			if ok != test.ok || wait < test.min || wait > test.max {
+				t.Errorf("retryAfter(%q) = %v, %t, want %v to %v, %t", test.header, wait, ok, test.min, test.max, test.ok)
+			}
+		})
+	}
+}
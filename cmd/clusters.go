@@ -0,0 +1,106 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type ClusterCount struct {
+	Total int
+	// Active counts the enabled certificate-based clusters and the agents
+	// with an active token.
+	Active int
+	// Available is false when cluster management is disabled or the token
+	// may not read the clusters.
+	Available bool
+}
+
+// projectCluster is the part of a certificate-based cluster that is needed.
+// gitlab.ProjectCluster does not decode whether the cluster is enabled.
+type projectCluster struct {
+	ID      int  `json:"id"`
+	Enabled bool `json:"enabled"`
+}
+
+// getProjectClusterCount counts the certificate-based clusters and the
+// Kubernetes agents of the project. The tokens of every agent are listed to
+// find out whether the agent is in use.
+func getProjectClusterCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (ClusterCount, error) {
+	req, err := git.NewRequest(http.MethodGet, fmt.Sprintf("projects/%s/clusters", gitlab.PathEscape(project.ID)), nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return ClusterCount{}, fmt.Errorf("failed to create cluster list request for project %s: %w", project.ID, err)
+	}
+
+	var clusters []projectCluster
+	resp, err := git.Do(req, &clusters)
+	if isFeatureUnavailable(resp, err) {
+		return ClusterCount{}, nil
+	}
+	if err != nil {
+		return ClusterCount{}, fmt.Errorf("failed to list clusters for project %s: %w", project.ID, err)
+	}
+
+	count := ClusterCount{Total: len(clusters), Available: true}
+	for _, cluster := range clusters {
+		if cluster.Enabled {
+			count.Active++
+		}
+	}
+
+	opt := &gitlab.ListAgentsOptions{Page: 1, PerPage: 100}
+	for {
+		agents, resp, err := git.ClusterAgents.ListAgents(project.ID, opt, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			return count, nil
+		}
+		if err != nil {
+			return ClusterCount{}, fmt.Errorf("failed to list Kubernetes agents for project %s: %w", project.ID, err)
+		}
+
+		for _, agent := range agents {
+			count.Total++
+			tokens, _, err := git.ClusterAgents.ListAgentTokens(project.ID, agent.ID, &gitlab.ListAgentTokensOptions{Page: 1, PerPage: 100}, gitlab.WithContext(ctx))
+			if err != nil {
+				return ClusterCount{}, fmt.Errorf("failed to list tokens of Kubernetes agent %s for project %s: %w", agent.Name, project.ID, err)
+			}
+			for _, token := range tokens {
+				if token.Status == "active" {
+					count.Active++
+					break
+				}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
+
+// getGroupClusterCount counts the certificate-based clusters of the group.
+// The REST API does not list the Kubernetes agents of a group.
+func getGroupClusterCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (ClusterCount, error) {
+	clusters, resp, err := git.GroupCluster.ListClusters(group.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return ClusterCount{}, nil
+	}
+	if err != nil {
+		return ClusterCount{}, fmt.Errorf("failed to list clusters for group %s: %w", group.ID, err)
+	}
+
+	count := ClusterCount{Total: len(clusters), Available: true}
+	for _, cluster := range clusters {
+		if cluster.Enabled {
+			count.Active++
+		}
+	}
+	return count, nil
+}
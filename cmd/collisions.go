@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// validateUniqueSeries checks that no two config entries push the same
+// metric with the same labels, which the registry would reject halfway
+// through a scrape. Labels read from group fields are only known while
+// scraping, so two groups reading the same fields are assumed to collide.
+func validateUniqueSeries(config *Config) error {
+	seen := map[string]string{}
+	check := func(entry string, names []string, labels prometheus.Labels) error {
+		for _, name := range names {
+			key := name + seriesLabels(labels)
+			if previous, ok := seen[key]; ok {
+				return fmt.Errorf("%s and %s both push %s with the labels %s, give them distinct extra_labels or remove one of them",
+					previous, entry, name, seriesLabels(labels))
+			}
+			seen[key] = entry
+		}
+		return nil
+	}
+	// Metrics of the same entry share the feature unavailable series of a
+	// feature, collectMetrics pushes it once.
+	checkFeatures := func(entry string, features []string, labels prometheus.Labels) error {
+		for _, feature := range features {
+			featureLabels := mergeLabels(labels, prometheus.Labels{"feature": feature})
+			key := "gitlab_scrape_feature_unavailable" + seriesLabels(featureLabels)
+			if previous, ok := seen[key]; ok && previous != entry {
+				return fmt.Errorf("%s and %s both push gitlab_scrape_feature_unavailable with the labels %s, give them distinct extra_labels or remove one of them",
+					previous, entry, seriesLabels(featureLabels))
+			}
+			seen[key] = entry
+		}
+		return nil
+	}
+
+	for i, group := range config.Groups {
+		labels := mergeLabels(config.DefaultLabels, group.ExtraLabels, prometheus.Labels{"group_id": group.ID})
+		for _, field := range group.LabelsFromGroupFields {
+			labels[field.LabelName] = "<" + field.Field + ">"
+		}
+		for _, definition := range groupMetrics {
+			if !definition.Enabled(group) {
+				continue
+			}
+			entry := fmt.Sprintf("groups[%d] (%s)", i, group.ID)
+			if err := check(entry, definition.names(group), labels); err != nil {
+				return err
+			}
+			if err := checkFeatures(entry, definition.Features, labels); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, project := range config.Projects {
+		labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"project_id": project.ID})
+		for _, definition := range projectMetrics {
+			if !definition.Enabled(project) {
+				continue
+			}
+			entry := fmt.Sprintf("projects[%d] (%s)", i, project.ID)
+			if err := check(entry, definition.names(project), labels); err != nil {
+				return err
+			}
+			if err := checkFeatures(entry, definition.Features, labels); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func seriesLabels(labels prometheus.Labels) string {
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	commentResourceIssue        = "issue"
+	commentResourceMergeRequest = "merge_request"
+	commentResourceBoth         = "both"
+)
+
+var commentResourceTypes = []string{commentResourceIssue, commentResourceMergeRequest, commentResourceBoth}
+
+const defaultCommentWindowDays = 30
+
+func (c *CommentConfig) windowDays() int {
+	if c.WithinDays <= 0 {
+		return defaultCommentWindowDays
+	}
+	return c.WithinDays
+}
+
+func (c *CommentConfig) counts(resourceType string) bool {
+	return c.ResourceType == "" || c.ResourceType == commentResourceBoth || c.ResourceType == resourceType
+}
+
+func validateCommentCounts(groups []GroupConfig) error {
+	for _, group := range groups {
+		if config := group.CommentCount; config != nil && config.ResourceType != "" && !slices.Contains(commentResourceTypes, config.ResourceType) {
+			return fmt.Errorf("unknown comment_count resource_type %q of group %s, expected one of %s",
+				config.ResourceType, group.ID, strings.Join(commentResourceTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// getGroupCommentCount sums the user comments on the issues and merge
+// requests of the group and its subgroups that were updated within
+// within_days, by resource type. The notes APIs only list the notes of a
+// single issue or merge request, so the comment counts of the list
+// responses are summed instead, which include older comments on the
+// updated items and exclude system notes.
+func getGroupCommentCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (map[string]int, error) {
+	config := group.CommentCount
+	updatedAfter := time.Now().AddDate(0, 0, -config.windowDays())
+	comments := map[string]int{}
+
+	if config.counts(commentResourceIssue) {
+		options := &gitlab.ListGroupIssuesOptions{
+			UpdatedAfter: gitlab.Ptr(updatedAfter),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+		for {
+			issues, resp, err := git.Issues.ListGroupIssues(group.ID, options, gitlab.WithContext(ctx))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list issues for group %s: %w", group.ID, err)
+			}
+
+			for _, issue := range issues {
+				comments[commentResourceIssue] += issue.UserNotesCount
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
+		}
+	}
+
+	if config.counts(commentResourceMergeRequest) {
+		options := &gitlab.ListGroupMergeRequestsOptions{
+			UpdatedAfter: gitlab.Ptr(updatedAfter),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+		for {
+			mergeRequests, resp, err := git.MergeRequests.ListGroupMergeRequests(group.ID, options, gitlab.WithContext(ctx))
+			if err != nil {
+				return nil, fmt.Errorf("failed to list merge requests for group %s: %w", group.ID, err)
+			}
+
+			for _, mr := range mergeRequests {
+				comments[commentResourceMergeRequest] += mr.UserNotesCount
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
+		}
+	}
+	return comments, nil
+}
@@ -0,0 +1,838 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+type ProjectCountConfig struct {
+	IncludeSubGroups *bool `json:"include_subgroups,omitempty"`
+	// MinStars additionally counts the projects with at least this many
+	// stars if positive.
+	MinStars int `json:"min_stars,omitempty"`
+	// MaxProjectsToScan limits the projects listed to count those with
+	// min_stars. Defaults to 1000.
+	MaxProjectsToScan int `json:"max_projects_to_scan,omitempty"`
+}
+
+type MemberCountConfig struct{}
+
+type GrowthRateConfig struct {
+	WindowDays int `json:"window_days,omitempty"`
+}
+
+type IssueSLAConfig struct {
+	DaysOverdue int `json:"days_overdue,omitempty"`
+}
+
+type GroupStatsConfig struct {
+	// AggregationFunc combines the project statistics when the group
+	// statistics endpoint is not available. Defaults to sum.
+	AggregationFunc string `json:"aggregation_func,omitempty"`
+}
+
+type InsightsConfig struct {
+	Query      string `json:"query"`
+	MetricName string `json:"metric_name,omitempty"`
+	Unit       string `json:"unit,omitempty"`
+}
+
+type FieldLabel struct {
+	Field     string `json:"field"`
+	LabelName string `json:"label_name"`
+}
+
+type PipelineDurationConfig struct {
+	WindowDays int `json:"window_days,omitempty"`
+	// Status of the pipelines to average, success or failed. Defaults to
+	// success.
+	Status string `json:"status,omitempty"`
+	// AggregationFunc combines the average durations of the projects of a
+	// group. Defaults to max, the slowest project. Ignored for projects.
+	AggregationFunc string `json:"aggregation_func,omitempty"`
+}
+
+type MRSizeConfig struct {
+	WindowDays   int `json:"window_days,omitempty"`
+	MaxMRsToScan int `json:"max_mrs_to_scan,omitempty"`
+	// State of the merge requests to scan, merged, opened, closed or all.
+	// Defaults to merged.
+	State string `json:"state,omitempty"`
+}
+
+type MRDiscussionConfig struct {
+	WindowDays   int `json:"window_days,omitempty"`
+	MaxMRsToScan int `json:"max_mrs_to_scan,omitempty"`
+	// Resolved only counts resolved or unresolved discussions if set.
+	Resolved *bool `json:"resolved,omitempty"`
+}
+
+type ApprovalBreakdownConfig struct {
+	WindowDays   int `json:"window_days,omitempty"`
+	MaxMRsToScan int `json:"max_mrs_to_scan,omitempty"`
+}
+
+type ApprovalWaitConfig struct {
+	// WindowDays limits the open merge requests to those created within
+	// the last window days. Defaults to 30.
+	WindowDays   int `json:"window_days,omitempty"`
+	MaxMRsToScan int `json:"max_mrs_to_scan,omitempty"`
+}
+
+type UserActivityConfig struct {
+	// TopN is the number of most active members pushed, which bounds the
+	// series of the metric. Defaults to 10.
+	TopN       int `json:"top_n,omitempty"`
+	WithinDays int `json:"within_days,omitempty"`
+	// MaxMembers is the number of members whose events are listed, one
+	// request each at least. Defaults to 200.
+	MaxMembers int `json:"max_members,omitempty"`
+}
+
+type ProtectionConfig struct {
+	// Weights sets the points of the branch_protection, push_rules,
+	// approvals and ci_config checks, 1 each by default.
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+type TriggerCountConfig struct {
+	// UnusedDays is the number of days after which an unused trigger is
+	// counted as stale. Defaults to 90.
+	UnusedDays int `json:"unused_days,omitempty"`
+}
+
+type ScheduleConfig struct {
+	// Active only counts active or inactive schedules if set.
+	Active *bool `json:"active,omitempty"`
+	// SplitByStatus pushes the active and inactive schedules as separate
+	// metrics instead of their total.
+	SplitByStatus bool `json:"split_by_status,omitempty"`
+}
+
+type SentryConfig struct{}
+
+type JiraConfig struct{}
+
+type HealthConfig struct {
+	// Weights sets the points of the readme, ci_config, branch_protection,
+	// recent_commit and few_open_issues checks, 1 each by default.
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// CommitWithinDays is how recent the last commit to the default branch
+	// must be. Defaults to 90.
+	CommitWithinDays int `json:"commit_within_days,omitempty"`
+	// MaxOpenIssues is the number of open issues the project must stay
+	// below. Defaults to 50.
+	MaxOpenIssues int `json:"max_open_issues,omitempty"`
+}
+
+type DormancyConfig struct {
+	// DormantDays is the number of days without activity after which a
+	// group is dormant. Defaults to 365.
+	DormantDays int `json:"dormant_days,omitempty"`
+}
+
+type MembershipChangeConfig struct {
+	// ByRole tracks the direct members of the group by role, so that a
+	// promotion shows up as a change in two roles.
+	ByRole bool `json:"by_role,omitempty"`
+}
+
+type ChatConfig struct{}
+
+type SharedProjectsConfig struct{}
+
+type READMEConfig struct{}
+
+type CIPresenceConfig struct{}
+
+type LastActivityAgeConfig struct{}
+
+type TopicConfig struct {
+	TopN int `json:"top_n,omitempty"`
+	// NoTopicCount also reports the number of projects without topics.
+	NoTopicCount bool `json:"no_topic_count,omitempty"`
+}
+
+type DependencyProxyConfig struct{}
+
+type BotMemberConfig struct{}
+
+type EpicCountConfig struct {
+	// State of the epics to count, opened or closed. Defaults to all.
+	State string `json:"state,omitempty"`
+	// WithinDays only counts the epics created in that many days if set.
+	WithinDays int `json:"within_days,omitempty"`
+}
+
+type InactivityConfig struct {
+	InactiveDays int `json:"inactive_days,omitempty"`
+	// MaxListed reports the age of that many of the longest inactive
+	// projects by name if set.
+	MaxListed int `json:"max_listed,omitempty"`
+}
+
+type WatcherCountConfig struct{}
+
+type AgeDistConfig struct {
+	// Buckets are the ascending bounds of the age buckets in years, 1 and 3
+	// by default.
+	Buckets []int `json:"buckets,omitempty"`
+}
+
+type OwnerCountConfig struct{}
+
+type BillableMemberConfig struct{}
+
+type CommentConfig struct {
+	WithinDays int `json:"within_days,omitempty"`
+	// ResourceType is issue, merge_request or both, the default.
+	ResourceType string `json:"resource_type,omitempty"`
+}
+
+type SeatConfig struct {
+	// TotalSeats is the number of seats of the subscription, which the API
+	// does not expose. 0 means unlimited seats.
+	TotalSeats int `json:"total_seats,omitempty"`
+}
+
+type IncidentConfig struct {
+	// Status only pushes the incident count of this escalation status out
+	// of triggered, acknowledged and resolved, if set.
+	Status string `json:"status,omitempty"`
+	// Priority only counts the incidents of this severity, e.g. critical,
+	// if set.
+	Priority string `json:"priority,omitempty"`
+}
+
+type FailedJobConfig struct {
+	WithinHours int `json:"within_hours,omitempty"`
+	// FailureReason only counts the jobs that failed for this reason, e.g.
+	// runner_system_failure, if set.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+type ExternalMemberConfig struct {
+	MaxMembersForExternalCheck int `json:"max_members_for_external_check,omitempty"`
+}
+
+type GroupConfig struct {
+	ID                          string                   `json:"id"`
+	Weight                      float64                  `json:"weight,omitempty"`
+	ScrapeOrder                 int                      `json:"scrape_order,omitempty"`
+	Timeout                     time.Duration            `json:"timeout,omitempty"`
+	DependsOn                   []string                 `json:"depends_on,omitempty"`
+	ProjectCount                *ProjectCountConfig      `json:"project_count,omitempty"`
+	ProjectCountGrowthRate      *GrowthRateConfig        `json:"project_count_growth_rate,omitempty"`
+	MemberCount                 *MemberCountConfig       `json:"member_count,omitempty"`
+	IssueSLABreaches            *IssueSLAConfig          `json:"issue_sla_breaches,omitempty"`
+	GroupStatistics             *GroupStatsConfig        `json:"group_statistics,omitempty"`
+	InsightsQuery               *InsightsConfig          `json:"insights_query,omitempty"`
+	PipelineAvgDuration         *PipelineDurationConfig  `json:"pipeline_avg_duration,omitempty"`
+	MergeRequestSize            *MRSizeConfig            `json:"merge_request_size,omitempty"`
+	ChatNotificationCount       *ChatConfig              `json:"chat_notification_count,omitempty"`
+	DependencyProxySize         *DependencyProxyConfig   `json:"dependency_proxy_size,omitempty"`
+	BotMemberCount              *BotMemberConfig         `json:"bot_member_count,omitempty"`
+	MergeRequestDiscussionCount *MRDiscussionConfig      `json:"merge_request_discussion_count,omitempty"`
+	MembershipChange            *MembershipChangeConfig  `json:"membership_change,omitempty"`
+	CountSharedProjects         *SharedProjectsConfig    `json:"count_shared_projects,omitempty"`
+	ProjectTopicCount           *TopicConfig             `json:"project_topic_count,omitempty"`
+	READMEPresence              *READMEConfig            `json:"readme_presence,omitempty"`
+	CIConfigPresence            *CIPresenceConfig        `json:"ci_config_presence,omitempty"`
+	LastActivityAge             *LastActivityAgeConfig   `json:"last_activity_age,omitempty"`
+	MemberExternalCount         *ExternalMemberConfig    `json:"member_external_count,omitempty"`
+	EpicCount                   *EpicCountConfig         `json:"epic_count,omitempty"`
+	ProjectInactivityAlert      *InactivityConfig        `json:"project_inactivity_alert,omitempty"`
+	WatcherCount                *WatcherCountConfig      `json:"watcher_count,omitempty"`
+	ApprovalRuleBreakdown       *ApprovalBreakdownConfig `json:"approval_rule_breakdown,omitempty"`
+	MRApprovalWaitTime          *ApprovalWaitConfig      `json:"mr_approval_wait_time,omitempty"`
+	OwnerCount                  *OwnerCountConfig        `json:"owner_count,omitempty"`
+	UserActivityStats           *UserActivityConfig      `json:"user_activity_stats,omitempty"`
+	DormancyDetection           *DormancyConfig          `json:"dormancy_detection,omitempty"`
+	FailedJobCount              *FailedJobConfig         `json:"failed_job_count,omitempty"`
+	ClusterCount                *ClusterConfig           `json:"cluster_count,omitempty"`
+	IncidentCount               *IncidentConfig          `json:"incident_count,omitempty"`
+	PushRulesEnabled            *PushRulesConfig         `json:"push_rules_enabled,omitempty"`
+	BillableMemberCount         *BillableMemberConfig    `json:"billable_member_count,omitempty"`
+	SeatUsageRatio              *SeatConfig              `json:"seat_usage_ratio,omitempty"`
+	CommentCount                *CommentConfig           `json:"comment_count,omitempty"`
+	ProjectAgeDistribution      *AgeDistConfig           `json:"project_age_distribution,omitempty"`
+
+	ExtraLabels           map[string]string `json:"extra_labels,omitempty"`
+	LabelsFromGroupFields []FieldLabel      `json:"labels_from_group_fields,omitempty"`
+	SubgroupsToScrape     []string          `json:"subgroups_to_scrape,omitempty"`
+
+	// MaxProjectsPerGroup caps the projects inspected by the metrics that
+	// iterate the projects of the group, such as chat_notification_count,
+	// which lists the integrations of every project and is slow for large
+	// groups. Unlimited if not set.
+	MaxProjectsPerGroup int `json:"max_projects_per_group,omitempty"`
+}
+
+type DependencyStatsConfig struct {
+	Ref string `json:"ref,omitempty"`
+}
+
+type CloneSizeConfig struct {
+	Branch string `json:"branch,omitempty"`
+}
+
+type FileCountConfig struct {
+	Path            string `json:"path,omitempty"`
+	Ref             string `json:"ref,omitempty"`
+	MaxDepth        int    `json:"max_depth,omitempty"`
+	MaxFilesToCount int    `json:"max_files_to_count,omitempty"`
+}
+
+type ContributorDomainConfig struct {
+	InternalDomains []string `json:"internal_domains"`
+}
+
+type PipelineFailureConfig struct {
+	WindowDays int `json:"window_days,omitempty"`
+}
+
+type PipelineCountConfig struct {
+	// Status is one of all, success, failed or canceled, or any other
+	// pipeline status. Defaults to all.
+	Status        string `json:"status,omitempty"`
+	Ref           string `json:"ref,omitempty"`
+	SplitByStatus bool   `json:"split_by_status,omitempty"`
+}
+
+type SecurityPolicyConfig struct{}
+
+type CoverageConfig struct {
+	Ref string `json:"ref,omitempty"`
+}
+
+type InfraTargetConfig struct{}
+
+// TerraformConfig is like InfraTargetConfig, but reports projects without
+// GitLab managed Terraform state as unavailable rather than having no states.
+type TerraformConfig struct{}
+
+type ServiceConfig struct {
+	// IntegrationType only counts integrations of this type, e.g. jira.
+	IntegrationType string `json:"integration_type,omitempty"`
+	SplitByType     bool   `json:"split_by_type,omitempty"`
+}
+
+type ForkNetworkConfig struct {
+	MaxDepth int `json:"max_depth,omitempty"`
+}
+
+type ProtectedTagConfig struct{}
+
+type BadgeConfig struct{}
+
+type ClusterConfig struct{}
+
+type OncallConfig struct{}
+
+type StatusPageConfig struct{}
+
+type PushRulesConfig struct{}
+
+type ErrorTrackingConfig struct {
+	// Status only counts the issues with this status, e.g. unresolved, if
+	// set.
+	Status string `json:"status,omitempty"`
+}
+
+type FeatureFlagConfig struct {
+	// Active only counts the active or the inactive feature flags, if set.
+	Active *bool `json:"active,omitempty"`
+}
+
+type AlertConfig struct {
+	// Status only counts the alerts with this status out of triggered,
+	// acknowledged, resolved and ignored, if set.
+	Status string `json:"status,omitempty"`
+}
+
+type ArtifactCountConfig struct {
+	// JobName only counts the artifacts of jobs with this name, if set.
+	JobName      string `json:"job_name,omitempty"`
+	MaxPipelines int    `json:"max_pipelines,omitempty"`
+	// IncludeExpired counts the expired artifacts that are still listed in
+	// gitlab_project_expired_artifact_count.
+	IncludeExpired bool `json:"include_expired,omitempty"`
+}
+
+type TestReportConfig struct {
+	// PipelineID defaults to the latest pipeline on the default branch.
+	PipelineID string `json:"pipeline_id,omitempty"`
+}
+
+type ProjectConfig struct {
+	ID                       string                   `json:"id"`
+	DependencyStats          *DependencyStatsConfig   `json:"dependency_stats,omitempty"`
+	CloneSize                *CloneSizeConfig         `json:"clone_size,omitempty"`
+	FileCount                *FileCountConfig         `json:"file_count,omitempty"`
+	ContributorDomainStats   *ContributorDomainConfig `json:"contributor_domain_stats,omitempty"`
+	PipelineFailureBreakdown *PipelineFailureConfig   `json:"pipeline_failure_breakdown,omitempty"`
+	PipelineCount            *PipelineCountConfig     `json:"pipeline_count,omitempty"`
+	SecurityPolicyCount      *SecurityPolicyConfig    `json:"security_policy_count,omitempty"`
+	PipelineAvgDuration      *PipelineDurationConfig  `json:"pipeline_avg_duration,omitempty"`
+	CodeCoverage             *CoverageConfig          `json:"code_coverage,omitempty"`
+	TestReportStats          *TestReportConfig        `json:"test_report_stats,omitempty"`
+	InfrastructureTarget     *InfraTargetConfig       `json:"infrastructure_target,omitempty"`
+	ServiceIntegrationCount  *ServiceConfig           `json:"service_integration_count,omitempty"`
+	ProjectForkNetwork       *ForkNetworkConfig       `json:"project_fork_network,omitempty"`
+	ProjectProtectedTagCount *ProtectedTagConfig      `json:"project_protected_tag_count,omitempty"`
+	BadgeCount               *BadgeConfig             `json:"badge_count,omitempty"`
+	CIJobArtifactCount       *ArtifactCountConfig     `json:"ci_job_artifact_count,omitempty"`
+	ClusterCount             *ClusterConfig           `json:"cluster_count,omitempty"`
+	TerraformStateCount      *TerraformConfig         `json:"terraform_state_count,omitempty"`
+	AlertCount               *AlertConfig             `json:"alert_count,omitempty"`
+	OncallScheduleCount      *OncallConfig            `json:"oncall_schedule_count,omitempty"`
+	FeatureFlagCount         *FeatureFlagConfig       `json:"feature_flag_count,omitempty"`
+	ErrorTrackingCount       *ErrorTrackingConfig     `json:"error_tracking_count,omitempty"`
+	StatusPageCount          *StatusPageConfig        `json:"status_page_count,omitempty"`
+	PushRulesEnabled         *PushRulesConfig         `json:"push_rules_enabled,omitempty"`
+	ProtectionScore          *ProtectionConfig        `json:"protection_score,omitempty"`
+	PipelineTriggerCount     *TriggerCountConfig      `json:"pipeline_trigger_count,omitempty"`
+	ScheduledPipelineCount   *ScheduleConfig          `json:"scheduled_pipeline_count,omitempty"`
+	SentryIntegrationStatus  *SentryConfig            `json:"sentry_integration_status,omitempty"`
+	JiraIntegrationStatus    *JiraConfig              `json:"jira_integration_status,omitempty"`
+	RepositoryHealth         *HealthConfig            `json:"repository_health,omitempty"`
+}
+
+type ApplicationStatsConfig struct{}
+
+// LanguageMatrixConfig bounds the language matrix to MaxGroups groups times
+// MaxLanguages languages, and rejects bounds beyond MaxSeries series.
+type LanguageMatrixConfig struct {
+	MaxGroups    int `json:"max_groups,omitempty"`
+	MaxLanguages int `json:"max_languages,omitempty"`
+	MaxSeries    int `json:"max_series,omitempty"`
+}
+
+// NamespaceConfig is a group or user namespace, by ID or path, whose
+// statistics are scraped.
+type NamespaceConfig struct {
+	ID string `json:"id"`
+	// Stats are the statistics to scrape out of storage, ci_minutes and
+	// repositories, all of them if empty.
+	Stats []string `json:"stats,omitempty"`
+}
+
+// CardinalityConfig limits the number of series pushed per scrape. Zero
+// values are unlimited.
+type CardinalityConfig struct {
+	MaxTimeSeriesTotal int `json:"max_time_series_total,omitempty"`
+	// MaxLabelValues limits the number of distinct values of labels by
+	// label name, e.g. project_name: 100.
+	MaxLabelValues map[string]int `json:"max_label_values,omitempty"`
+}
+
+// ConnectionPoolConfig holds the connection pool limits of the GitLab HTTP
+// client. Zero values keep Go's defaults of 100 idle connections, 2 idle
+// connections per host and no limit of connections per host.
+type ConnectionPoolConfig struct {
+	MaxIdleConns        int `json:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+	MaxConnsPerHost     int `json:"max_conns_per_host,omitempty"`
+}
+
+// currentSchemaVersion is the schema version of configs written for this
+// version of the scraper.
+const currentSchemaVersion = "v1"
+
+// SupportedSchemaVersions are the config schema versions this version of the
+// scraper can read.
+var SupportedSchemaVersions = []string{"v1"}
+
+type Config struct {
+	GitLabURL         string            `json:"gitlab_url,omitempty"`
+	PushGatewayURL    string            `json:"push_gateway_url,omitempty"`
+	JobName           string            `json:"job_name,omitempty"`
+	DefaultLabels     map[string]string `json:"default_labels"`
+	Aliases           map[string]string `json:"aliases,omitempty"`
+	MaxRetryAfterWait time.Duration     `json:"max_retry_after_wait,omitempty"`
+	ScrapeJitter      time.Duration     `json:"scrape_jitter,omitempty"`
+	GlobalTimeout     time.Duration     `json:"global_timeout,omitempty"`
+	MetricTTL         time.Duration     `json:"metric_ttl,omitempty"`
+	ListenAddr        string            `json:"listen_addr,omitempty"`
+	TokenType         string            `json:"token_type,omitempty"`
+	Groups            []GroupConfig     `json:"groups"`
+	Projects          []ProjectConfig   `json:"projects"`
+
+	ApplicationStats *ApplicationStatsConfig `json:"application_stats,omitempty"`
+
+	// GroupProjectLanguageMatrix pushes the languages of the projects of
+	// every group, labelled by group and language.
+	GroupProjectLanguageMatrix *LanguageMatrixConfig `json:"group_project_language_matrix,omitempty"`
+
+	// Namespaces are scraped for their storage and CI/CD minutes statistics.
+	Namespaces []NamespaceConfig `json:"namespaces,omitempty"`
+
+	// DefaultGroupMetrics holds the metrics of every group that does not
+	// configure them itself. Its ID is ignored.
+	DefaultGroupMetrics GroupConfig `json:"default_group_metrics,omitempty"`
+
+	// PushGatewayGroupingFromEnv maps Push Gateway grouping keys to the
+	// environment variables holding their values, e.g. pod_name: POD_NAME.
+	PushGatewayGroupingFromEnv map[string]string `json:"push_gateway_grouping_from_env,omitempty"`
+
+	// GlobalExcludePatterns are regular expressions for the path or name of
+	// projects that are skipped in every group.
+	GlobalExcludePatterns []string `json:"global_exclude_patterns,omitempty"`
+
+	// DisableMetrics are metric names or globs matching them, e.g.
+	// gitlab_group_*_age_seconds, that are neither collected nor pushed.
+	DisableMetrics []string `json:"disable_metrics,omitempty"`
+
+	// LabelSanitizer handles characters other than letters, digits and
+	// underscores in label values, such as the slashes of project paths:
+	// strict fails the scrape, replace replaces them with underscores and
+	// encode percent-encodes them. Label values are pushed as they are if
+	// unset.
+	LabelSanitizer string `json:"label_sanitizer,omitempty"`
+
+	// MetricAliases pushes a copy of metrics under another name, by the name
+	// they are collected with, e.g. to keep pushing the old name of a renamed
+	// metric until every dashboard moved to the new one.
+	MetricAliases map[string]string `json:"metric_aliases,omitempty"`
+
+	// MetricCardinality drops series beyond its limits before they are
+	// pushed, the metrics of the groups with the lowest weight first.
+	MetricCardinality CardinalityConfig `json:"metric_cardinality,omitempty"`
+
+	// ForceMetricType sets the type of metrics by name to gauge or counter,
+	// for gauges that only ever increase, e.g. gitlab_group_pipeline_failed_total.
+	// Counters are kept from decreasing with the state file.
+	ForceMetricType map[string]string `json:"force_metric_type,omitempty"`
+
+	// MetricHelpOverrides replaces the help text of metrics by their name or
+	// a glob matching it, e.g. gitlab_group_*.
+	MetricHelpOverrides map[string]string `json:"metric_help_overrides,omitempty"`
+
+	// SensitiveFields are the paths of config fields that are redacted
+	// wherever the config is written out, e.g. groups.*.access_token. The
+	// access token is always redacted.
+	SensitiveFields []string `json:"sensitive_fields,omitempty"`
+
+	// TrackProjectTransfers reports configured projects that moved to
+	// another namespace since the previous scrape, using the state file.
+	TrackProjectTransfers bool `json:"track_project_transfers,omitempty"`
+	// TrackProjectArchival reports configured projects that were archived
+	// since the previous scrape, using the state file.
+	TrackProjectArchival bool `json:"track_project_archival,omitempty"`
+
+	// ConnectionPool limits the connections to the GitLab API.
+	ConnectionPool ConnectionPoolConfig `json:"connection_pool,omitempty"`
+
+	// PanicRecovery turns a panic while scraping a group into
+	// gitlab_scrape_panic_total for that group, so that the scrape of the
+	// other groups continues. Panics in goroutines started by a metric are
+	// not recovered.
+	PanicRecovery bool `json:"panic_recovery,omitempty"`
+
+	// GracefulPushTimeout is how long a daemon that is asked to shut down
+	// waits for a push in flight. Defaults to 10s.
+	GracefulPushTimeout time.Duration `json:"graceful_push_timeout,omitempty"`
+
+	// SchemaVersion is the version of the config schema. A config without
+	// one is read as the current version.
+	SchemaVersion string `json:"schema_version,omitempty"`
+
+	excludePatterns []*regexp.Regexp
+}
+
+// stdinConfigTimeout is how long to wait for the first byte of a config
+// piped through stdin before assuming nothing is piped.
+const stdinConfigTimeout = 5 * time.Second
+
+// loadConfig reads the config file at path and exits if it is invalid.
+func loadConfig(path string) *Config {
+	config, err := readConfig(path)
+	if err != nil {
+		fatalf("Failed to load config: %v", err)
+	}
+	return config
+}
+
+// readConfig reads the config file at path. Environment variables in the
+// path are expanded, so entrypoints that do not run through a shell can
+// still pass paths like $CONFIG_DIR/config.json.
+func readConfig(path string) (*Config, error) {
+	return readConfigFile(path, true)
+}
+
+// readConfigFile reads the config file at path like readConfig. Unless
+// overrides is set, the values set through flags and environment variables
+// are ignored, so that the config is only what the file says.
+func readConfigFile(path string, overrides bool) (*Config, error) {
+	path = os.ExpandEnv(path)
+	var (
+		data       []byte
+		configType string
+		err        error
+	)
+	if path == "-" {
+		data, configType, err = readConfigFromStdin()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config from stdin: %w", err)
+		}
+	} else {
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		configType = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	if configTemplate {
+		data, err = renderConfigTemplate(filepath.Base(path), data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	viper.SetConfigType(configType)
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Viper drops empty maps, but an empty map such as "member_count": {}
+	// enables a metric. The document is therefore decoded as is and only the
+	// values set through flags and environment variables are taken from viper.
+	var document map[string]any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if document == nil {
+		document = map[string]any{}
+	}
+	if err := checkSchemaVersion(document["schema_version"]); err != nil {
+		return nil, err
+	}
+	for _, key := range envKeys() {
+		if overrides && viper.IsSet(key) {
+			document[key] = viper.Get(key)
+		}
+	}
+
+	var (
+		config   Config
+		metadata mapstructure.Metadata
+	)
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "json",
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		Metadata: &metadata,
+		Result:   &config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	if err := decoder.Decode(document); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	// Fields of a newer minor revision of the schema are ignored rather than
+	// rejected, so that a config can be shared between scraper versions.
+	for _, key := range metadata.Unused {
+		if key != "access_token" {
+			warnf("unknown config field %s is ignored", key)
+		}
+	}
+	if err := checkDeprecations(document); err != nil {
+		return nil, err
+	}
+	if config.SchemaVersion == "" {
+		config.SchemaVersion = currentSchemaVersion
+	}
+
+	for i := range config.Groups {
+		config.Groups[i] = applyDefaults(config.DefaultGroupMetrics, &config.Groups[i])
+	}
+
+	if err := expandAliases(&config); err != nil {
+		return nil, fmt.Errorf("failed to expand label aliases: %w", err)
+	}
+
+	if err := validateFieldLabels(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateTerraformStateCounts(config.Projects); err != nil {
+		return nil, err
+	}
+
+	if err := validateUniqueSeries(&config); err != nil {
+		return nil, fmt.Errorf("duplicate metrics: %w", err)
+	}
+
+	if err := validateGroupDependencies(config.Groups); err != nil {
+		return nil, fmt.Errorf("invalid group dependencies: %w", err)
+	}
+
+	if err := validateAggregations(config.Groups); err != nil {
+		return nil, err
+	}
+
+	if err := validateInsightsQueries(config.Groups); err != nil {
+		return nil, err
+	}
+
+	if err := validateCommentCounts(config.Groups); err != nil {
+		return nil, err
+	}
+
+	if err := validateProjectCounts(config.Groups); err != nil {
+		return nil, err
+	}
+
+	if err := validateProjectAgeBuckets(config.Groups); err != nil {
+		return nil, err
+	}
+
+	if err := validateProtectionScores(config.Projects); err != nil {
+		return nil, err
+	}
+
+	if err := validateRepositoryHealth(config.Projects); err != nil {
+		return nil, err
+	}
+
+	if err := validateScheduledPipelineCounts(config.Projects); err != nil {
+		return nil, err
+	}
+
+	if err := validateTokenType(config.TokenType); err != nil {
+		return nil, err
+	}
+
+	if err := validateHelpOverrides(config.MetricHelpOverrides); err != nil {
+		return nil, err
+	}
+
+	if err := validateDisabledMetrics(config.DisableMetrics); err != nil {
+		return nil, err
+	}
+
+	if err := validateForceMetricTypes(config.ForceMetricType); err != nil {
+		return nil, err
+	}
+
+	if err := validateMetricAliases(config.MetricAliases); err != nil {
+		return nil, err
+	}
+
+	if err := validateLabelSanitizer(config.LabelSanitizer); err != nil {
+		return nil, err
+	}
+
+	if err := validateCardinality(config.MetricCardinality); err != nil {
+		return nil, err
+	}
+
+	if err := validateLanguageMatrix(&config); err != nil {
+		return nil, err
+	}
+
+	if err := validateNamespaces(config.Namespaces); err != nil {
+		return nil, err
+	}
+
+	if config.excludePatterns, err = compileExcludePatterns(config.GlobalExcludePatterns); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// checkSchemaVersion fails with a migration hint if version is set to a
+// schema version this binary cannot read.
+func checkSchemaVersion(version any) error {
+	if version == nil {
+		return nil
+	}
+	v := fmt.Sprint(version)
+	if slices.Contains(SupportedSchemaVersions, v) {
+		return nil
+	}
+	return fmt.Errorf("unsupported config schema_version %q, this version of the scraper reads %s. "+
+		"Either update the scraper or migrate the config as described in the \"Config schema versions\" section of the README",
+		v, strings.Join(SupportedSchemaVersions, ", "))
+}
+
+// readConfigFromStdin reads the config passed with --config -. As stdin has
+// no file extension, JSON is assumed when the input starts with a brace and
+// YAML otherwise.
+func readConfigFromStdin() (data []byte, configType string, err error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return nil, "", errors.New("stdin is a terminal, pipe the config into the command when using --config -")
+	}
+
+	// Not every platform reports a terminal through the file mode, so give
+	// up if nothing arrives instead of blocking forever.
+	reader := bufio.NewReader(os.Stdin)
+	peeked := make(chan error, 1)
+	go func() {
+		_, err := reader.Peek(1)
+		peeked <- err
+	}()
+	select {
+	case err := <-peeked:
+		if err == io.EOF {
+			return nil, "", errors.New("stdin is empty")
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	case <-time.After(stdinConfigTimeout):
+		return nil, "", fmt.Errorf("no config received on stdin within %s", stdinConfigTimeout)
+	}
+
+	data, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+
+	configType = "yaml"
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		configType = "json"
+	}
+	return data, configType, nil
+}
+
+// applyDefaults returns group with every metric it leaves unset copied from
+// defaults. Metrics are the pointer fields of GroupConfig.
+func applyDefaults(defaults GroupConfig, group *GroupConfig) GroupConfig {
+	merged := *group
+	mergedValue := reflect.ValueOf(&merged).Elem()
+	defaultsValue := reflect.ValueOf(defaults)
+	for i := range mergedValue.NumField() {
+		field := mergedValue.Field(i)
+		if field.Kind() != reflect.Pointer || !field.IsNil() || defaultsValue.Field(i).IsNil() {
+			continue
+		}
+		metric := reflect.New(field.Type().Elem())
+		metric.Elem().Set(defaultsValue.Field(i).Elem())
+		field.Set(metric)
+	}
+	return merged
+}
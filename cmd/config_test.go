@@ -0,0 +1,38 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvironmentVariablesAreExpanded(t *testing.T) {
+	dir := t.TempDir()
+	config := `push_gateway_url: "$TEST_PUSH_GATEWAY_HOST/push"
+gitlab_url: "https://${TEST_GITLAB_HOST}"
+groups: []
+projects: []
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_CONFIG_DIR", dir)
+	t.Setenv("TEST_PUSH_GATEWAY_HOST", "http://pushgateway:9091")
+	t.Setenv("TEST_GITLAB_HOST", "gitlab.example.com")
+
+	loaded, err := readConfig("$TEST_CONFIG_DIR/config.yaml")
+	if err != nil {
+		t.Fatalf("config path was not expanded: %v", err)
+	}
+	applyConnectionSettings(loaded)
+
+	if want := "http://pushgateway:9091/push"; loaded.PushGatewayURL != want {
+		t.Errorf("push_gateway_url = %q, want %q", loaded.PushGatewayURL, want)
+	}
+	if want := "https://gitlab.example.com"; loaded.GitLabURL != want {
+		t.Errorf("gitlab_url = %q, want %q", loaded.GitLabURL, want)
+	}
+}
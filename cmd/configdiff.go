@@ -0,0 +1,219 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFrom string
+	diffTo   string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with config files",
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Print the differences between two config files",
+	Long: `This command loads two configuration files and prints the groups and projects that were
+added or removed and every setting that changed, one per line, e.g. for the description of a
+pull request. Defaults are applied before comparing, so a metric moved into
+default_group_metrics shows up only where its effective settings changed. It exits with a
+non-zero status if the configs differ. Values set through flags and environment variables
+are not applied, and the sensitive_fields of each config are redacted.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		changes := diffConfigs(loadConfigFile(diffFrom), loadConfigFile(diffTo))
+		printConfigDiff(os.Stdout, changes)
+		if len(changes) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configDiffCmd)
+	configDiffCmd.Flags().StringVar(&diffFrom, "from", "", "config file to compare from (required)")
+	configDiffCmd.Flags().StringVar(&diffTo, "to", "", "config file to compare to (required)")
+	configDiffCmd.MarkFlagRequired("from")
+	configDiffCmd.MarkFlagRequired("to")
+}
+
+// loadConfigFile reads the config file at path without the values of flags
+// and environment variables and exits if it is invalid.
+func loadConfigFile(path string) *Config {
+	config, err := readConfigFile(path, false)
+	if err != nil {
+		fatalf("Failed to load config %s: %v", path, err)
+	}
+	return config
+}
+
+// diffConfigs compares the JSON form of both configs. Groups and projects
+// are matched by ID rather than position, so that reordering them is not a
+// change. Sensitive fields are redacted before comparing, so a changed
+// secret does not show up.
+func diffConfigs(from, to *Config) []string {
+	fromDoc, toDoc := redactedConfig(from), redactedConfig(to)
+
+	var changes []string
+	for _, targets := range []struct{ key, kind string }{{"groups", "group"}, {"projects", "project"}} {
+		fromTargets, fromOrder := targetsByID(fromDoc[targets.key])
+		toTargets, toOrder := targetsByID(toDoc[targets.key])
+		delete(fromDoc, targets.key)
+		delete(toDoc, targets.key)
+
+		for _, id := range toOrder {
+			if _, ok := fromTargets[id]; !ok {
+				changes = append(changes, fmt.Sprintf("Added %s %s", targets.kind, id))
+			}
+		}
+		for _, id := range fromOrder {
+			if _, ok := toTargets[id]; !ok {
+				changes = append(changes, fmt.Sprintf("Removed %s %s", targets.kind, id))
+			}
+		}
+		for _, id := range toOrder {
+			if target, ok := fromTargets[id]; ok {
+				changes = diffValues(changes, fmt.Sprintf("%s[%s]", targets.key, id), target, toTargets[id])
+			}
+		}
+	}
+	return diffValues(changes, "", fromDoc, toDoc)
+}
+
+func configDocument(config *Config) map[string]any {
+	return documentValue(reflect.ValueOf(*config)).(map[string]any)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// documentValue converts v to the form it has in a JSON config, except
+// that durations keep their readable form, e.g. 5s.
+func documentValue(v reflect.Value) any {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return documentValue(v.Elem())
+	case reflect.Struct:
+		document := map[string]any{}
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if strings.Contains(options, "omitempty") && v.Field(i).IsZero() {
+				continue
+			}
+			document[name] = documentValue(v.Field(i))
+		}
+		return document
+	case reflect.Map:
+		document := map[string]any{}
+		for _, key := range v.MapKeys() {
+			document[fmt.Sprint(key.Interface())] = documentValue(v.MapIndex(key))
+		}
+		return document
+	case reflect.Slice, reflect.Array:
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = documentValue(v.Index(i))
+		}
+		return list
+	default:
+		return v.Interface()
+	}
+}
+
+// targetsByID indexes a list of groups or projects by their ID, which is
+// dropped from the entries as it is part of their path.
+func targetsByID(value any) (map[string]any, []string) {
+	targets := map[string]any{}
+	var order []string
+	list, _ := value.([]any)
+	for _, item := range list {
+		target, _ := item.(map[string]any)
+		id, _ := target["id"].(string)
+		delete(target, "id")
+		targets[id] = target
+		order = append(order, id)
+	}
+	return targets, order
+}
+
+// diffValues appends a line for every value that differs between from and
+// to, descending into objects to name the changed field.
+func diffValues(changes []string, path string, from, to any) []string {
+	fromMap, fromIsMap := from.(map[string]any)
+	toMap, toIsMap := to.(map[string]any)
+	if !fromIsMap || !toIsMap {
+		if fromJSON, toJSON := formatDiffValue(from), formatDiffValue(to); fromJSON != toJSON {
+			changes = append(changes, fmt.Sprintf("Changed %s: %s -> %s", path, fromJSON, toJSON))
+		}
+		return changes
+	}
+
+	keys := slices.Sorted(maps.Keys(fromMap))
+	for key := range toMap {
+		if _, ok := fromMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fromValue, inFrom := fromMap[key]
+		toValue, inTo := toMap[key]
+		switch {
+		case !inFrom:
+			changes = append(changes, fmt.Sprintf("Set %s: %s", fieldPath, formatDiffValue(toValue)))
+		case !inTo:
+			changes = append(changes, fmt.Sprintf("Unset %s (was %s)", fieldPath, formatDiffValue(fromValue)))
+		default:
+			changes = diffValues(changes, fieldPath, fromValue, toValue)
+		}
+	}
+	return changes
+}
+
+func formatDiffValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func printConfigDiff(out io.Writer, changes []string) {
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "The configs do not differ")
+		return
+	}
+	for _, change := range changes {
+		fmt.Fprintf(out, "- %s\n", change)
+	}
+}
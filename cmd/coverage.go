@@ -0,0 +1,41 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// getCodeCoverage reads the coverage of the latest pipeline on the ref,
+// which GitLab aggregates from the coverage of its jobs. ok is false if the
+// project does not report coverage or has no pipeline on the ref.
+func getCodeCoverage(ctx context.Context, git *gitlab.Client, project ProjectConfig) (coverage float64, ok bool, err error) {
+	options := &gitlab.GetLatestPipelineOptions{}
+	if project.CodeCoverage.Ref != "" {
+		options.Ref = gitlab.Ptr(project.CodeCoverage.Ref)
+	}
+
+	pipeline, resp, err := git.Pipelines.GetLatestPipeline(project.ID, options, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("No pipelines in project %s, reporting no code coverage\n", project.ID)
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get latest pipeline for project %s: %w", project.ID, err)
+	}
+	if pipeline.Coverage == "" {
+		return 0, false, nil
+	}
+
+	coverage, err = strconv.ParseFloat(pipeline.Coverage, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse coverage %q of pipeline %d for project %s: %w", pipeline.Coverage, pipeline.ID, project.ID, err)
+	}
+	return coverage, true, nil
+}
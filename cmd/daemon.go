@@ -0,0 +1,205 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	interval        time.Duration
+	noInitialJitter bool
+)
+
+func init() {
+	scrapeCmd.Flags().DurationVar(&interval, "interval", 0, "Keep running and scrape at this interval instead of scraping once")
+	scrapeCmd.Flags().BoolVar(&noInitialJitter, "no-initial-jitter", false, "Skip the scrape jitter for the first scrape in daemon mode")
+}
+
+const defaultGracefulPushTimeout = 10 * time.Second
+
+func (c *Config) gracefulPushTimeout() time.Duration {
+	if c.GracefulPushTimeout <= 0 {
+		return defaultGracefulPushTimeout
+	}
+	return c.GracefulPushTimeout
+}
+
+// pushes tracks the pushes in flight, so that a shutdown can wait for them
+// instead of leaving a partial push behind. Once closed, no new push starts.
+var pushes struct {
+	sync.Mutex
+	active sync.WaitGroup
+	closed bool
+}
+
+// beginPush registers a push and returns false if the daemon is shutting
+// down, in which case the push must not be started.
+func beginPush() bool {
+	pushes.Lock()
+	defer pushes.Unlock()
+	if pushes.closed {
+		return false
+	}
+	pushes.active.Add(1)
+	return true
+}
+
+func endPush() { pushes.active.Done() }
+
+// waitForPushes stops new pushes and waits up to timeout for the ones in
+// flight. It returns false if they did not finish in time.
+func waitForPushes(timeout time.Duration) bool {
+	pushes.Lock()
+	pushes.closed = true
+	pushes.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		pushes.active.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// runDaemon scrapes every interval until it receives SIGINT or SIGTERM. A
+// push in flight at that point gets graceful_push_timeout to finish, a scrape
+// that has not started pushing yet is abandoned.
+func runDaemon(ctx context.Context, config *Config, accessToken string) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if pidFile != "" {
+		removePIDFile, err := writePIDFile(pidFile)
+		if err != nil {
+			fatalf("Cannot start daemon: %v", err)
+		}
+		defer removePIDFile()
+	}
+
+	if config.ScrapeJitter >= interval {
+		warnf("scrape_jitter %s is not shorter than the interval %s", config.ScrapeJitter, interval)
+	}
+
+	if config.MetricTTL > 0 {
+		staleness = newStalenessTracker(config.MetricTTL)
+	}
+
+	latest := &latestGatherer{}
+	var hub *metricsHub
+	if streamMetrics {
+		hub = newMetricsHub()
+	}
+	if listenAddress != "" {
+		defer startServer(listenAddress, latest, hub)()
+	}
+
+	reloader := newConfigReloader(configFile, config)
+	go reloader.watch(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		config := reloader.Config()
+		if !first || !noInitialJitter {
+			if !sleepJitter(ctx, config.ScrapeJitter) {
+				return
+			}
+		}
+		type result struct {
+			gatherer prometheus.Gatherer
+			err      error
+		}
+		scraped := make(chan result, 1)
+		go func() {
+			gatherer, err := scrape(config, accessToken)
+			scraped <- result{gatherer, err}
+		}()
+
+		var gatherer prometheus.Gatherer
+		select {
+		case r := <-scraped:
+			gatherer = r.gatherer
+			if r.err != nil {
+				warnf("scrape failed, scraping again in %s: %v", interval, r.err)
+			}
+		case <-ctx.Done():
+			if !waitForPushes(config.gracefulPushTimeout()) {
+				warnf("push aborted, it did not finish within %s of the shutdown", config.gracefulPushTimeout())
+				if pidFile != "" {
+					os.Remove(pidFile)
+				}
+				os.Exit(2)
+			}
+			return
+		}
+		if gatherer != nil {
+			latest.Store(gatherer)
+		}
+		if hub != nil && gatherer != nil {
+			if err := publishMetrics(hub, gatherer); err != nil {
+				warnf("failed to stream metrics: %v", err)
+			}
+		}
+
+		if !waitForNextScrape(ctx, ticker, reloader) {
+			return
+		}
+	}
+}
+
+// waitForNextScrape waits for the next tick of ticker and applies the config
+// reloads requested in the meantime. It returns false if ctx is done first.
+func waitForNextScrape(ctx context.Context, ticker *time.Ticker, reloader *configReloader) bool {
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-reloader.requests:
+			reloader.reload()
+		case <-ticker.C:
+			return true
+		}
+	}
+}
+
+// sleepJitter sleeps a uniformly random duration between 0 and jitter. It
+// returns false if ctx is done before the sleep is over.
+func sleepJitter(ctx context.Context, jitter time.Duration) bool {
+	if jitter <= 0 {
+		return ctx.Err() == nil
+	}
+
+	delay := rand.N(jitter)
+	fmt.Printf("Waiting %s before scraping\n", delay.Round(time.Millisecond))
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+func publishMetrics(hub *metricsHub, gatherer prometheus.Gatherer) error {
+	values, err := gatherValues(gatherer)
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	return hub.publish(values, time.Now())
+}
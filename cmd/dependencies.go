@@ -0,0 +1,131 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const dependencyScanningReport = "gl-dependency-scanning-report.json"
+
+type DependencyStats struct {
+	Total                   int
+	Vulnerable              int
+	CriticalVulnerabilities int
+	// Severities counts the vulnerabilities by lower-cased severity.
+	Severities map[string]int
+}
+
+type dependencyReportPackage struct {
+	Package struct {
+		Name string `json:"name"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+func (d dependencyReportPackage) key() string {
+	return d.Package.Name + "@" + d.Version
+}
+
+type dependencyReport struct {
+	Vulnerabilities []struct {
+		Severity string `json:"severity"`
+		Location struct {
+			File       string                  `json:"file"`
+			Dependency dependencyReportPackage `json:"dependency"`
+		} `json:"location"`
+	} `json:"vulnerabilities"`
+	DependencyFiles []struct {
+		Path         string                    `json:"path"`
+		Dependencies []dependencyReportPackage `json:"dependencies"`
+	} `json:"dependency_files"`
+}
+
+func getDependencyStats(ctx context.Context, git *gitlab.Client, project ProjectConfig) (DependencyStats, error) {
+	options := &gitlab.GetLatestPipelineOptions{}
+	if project.DependencyStats.Ref != "" {
+		options.Ref = gitlab.Ptr(project.DependencyStats.Ref)
+	}
+
+	pipeline, _, err := git.Pipelines.GetLatestPipeline(project.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return DependencyStats{}, fmt.Errorf("failed to get latest pipeline for project %s: %w", project.ID, err)
+	}
+
+	job, err := findDependencyScanningJob(ctx, git, project, pipeline.ID)
+	if err != nil {
+		return DependencyStats{}, err
+	}
+	if job == nil {
+		fmt.Printf("No dependency scanning report found in pipeline %d for project %s\n", pipeline.ID, project.ID)
+		return DependencyStats{}, nil
+	}
+
+	artifact, _, err := git.Jobs.DownloadSingleArtifactsFile(project.ID, job.ID, dependencyScanningReport, gitlab.WithContext(ctx))
+	if err != nil {
+		return DependencyStats{}, fmt.Errorf("failed to download dependency scanning report for project %s: %w", project.ID, err)
+	}
+
+	var report dependencyReport
+	if err := json.NewDecoder(artifact).Decode(&report); err != nil {
+		return DependencyStats{}, fmt.Errorf("failed to parse dependency scanning report for project %s: %w", project.ID, err)
+	}
+
+	stats := DependencyStats{Severities: map[string]int{}}
+	for _, file := range report.DependencyFiles {
+		stats.Total += len(file.Dependencies)
+	}
+
+	vulnerable := map[string]struct{}{}
+	for _, vulnerability := range report.Vulnerabilities {
+		vulnerable[vulnerability.Location.File+":"+vulnerability.Location.Dependency.key()] = struct{}{}
+		stats.Severities[strings.ToLower(vulnerability.Severity)]++
+		if strings.EqualFold(vulnerability.Severity, "critical") {
+			stats.CriticalVulnerabilities++
+		}
+	}
+	stats.Vulnerable = len(vulnerable)
+
+	return stats, nil
+}
+
+// findDependencyScanningJob returns the first job of the pipeline with a
+// dependency scanning report, or nil if there is none.
+func findDependencyScanningJob(ctx context.Context, git *gitlab.Client, project ProjectConfig, pipelineID int) (*gitlab.Job, error) {
+	options := &gitlab.ListJobsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	for {
+		jobs, resp, err := git.Jobs.ListPipelineJobs(project.ID, pipelineID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs of pipeline %d for project %s: %w", pipelineID, project.ID, err)
+		}
+		if i := slices.IndexFunc(jobs, func(job *gitlab.Job) bool { return hasArtifact(job, "dependency_scanning") }); i >= 0 {
+			return jobs[i], nil
+		}
+
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+func hasArtifact(job *gitlab.Job, fileType string) bool {
+	for _, artifact := range job.Artifacts {
+		if artifact.FileType == fileType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type DependencyProxySize struct {
+	Bytes int64
+	Blobs int
+	// Available is false for groups without the Dependency Proxy.
+	Available bool
+}
+
+const dependencyProxyQuery = `query($fullPath: ID!) {
+  group(fullPath: $fullPath) {
+    dependencyProxyTotalSizeInBytes
+    dependencyProxyBlobCount
+  }
+}`
+
+type dependencyProxyResponse struct {
+	Group *struct {
+		// BigInt values are encoded as strings.
+		TotalSizeInBytes json.RawMessage `json:"dependencyProxyTotalSizeInBytes"`
+		BlobCount        *int            `json:"dependencyProxyBlobCount"`
+	} `json:"group"`
+}
+
+// getDependencyProxySize reads the storage used by the Dependency Proxy cache
+// of the group. The REST API does not expose it, so it is read through
+// GraphQL, which needs the full path of the group.
+func getDependencyProxySize(ctx context.Context, git *gitlab.Client, group GroupConfig) (DependencyProxySize, error) {
+	fullPath, err := groupFullPath(ctx, git, group.ID)
+	if err != nil {
+		return DependencyProxySize{}, err
+	}
+
+	var proxy dependencyProxyResponse
+	ok, err := doGraphQL(ctx, git, dependencyProxyQuery, map[string]any{"fullPath": fullPath}, &proxy)
+	if err != nil {
+		return DependencyProxySize{}, fmt.Errorf("failed to get Dependency Proxy size for group %s: %w", group.ID, err)
+	}
+
+	result := proxy.Group
+	if !ok || result == nil || result.BlobCount == nil {
+		return DependencyProxySize{}, nil
+	}
+
+	size, err := strconv.ParseInt(strings.Trim(string(result.TotalSizeInBytes), `"`), 10, 64)
+	if err != nil {
+		return DependencyProxySize{}, fmt.Errorf("failed to parse Dependency Proxy size %s of group %s: %w", result.TotalSizeInBytes, group.ID, err)
+	}
+	return DependencyProxySize{Bytes: size, Blobs: *result.BlobCount, Available: true}, nil
+}
+
+// groupFullPath returns the full path GraphQL identifies groups by, looking
+// it up if groupID is numeric.
+func groupFullPath(ctx context.Context, git *gitlab.Client, groupID string) (string, error) {
+	if _, err := strconv.Atoi(groupID); err != nil {
+		return groupID, nil
+	}
+	g, _, err := git.Groups.GetGroup(groupID, &gitlab.GetGroupOptions{WithProjects: gitlab.Ptr(false)}, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to get group %s: %w", groupID, err)
+	}
+	return g.FullPath, nil
+}
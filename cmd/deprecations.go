@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DeprecatedField describes a config field that is still read but will be
+// removed.
+type DeprecatedField struct {
+	// RemovedIn is the version of the scraper that no longer reads the
+	// field.
+	RemovedIn string
+	// Replacement is the path of the field to use instead, if any.
+	Replacement string
+}
+
+// DeprecatedFields maps the paths of deprecated config fields to their
+// deprecation. Paths are written like sensitive_fields, e.g.
+// groups.*.member_count.
+var DeprecatedFields = map[string]DeprecatedField{}
+
+var (
+	deprecationWarnings bool
+	fatalDeprecations   bool
+)
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&deprecationWarnings, "deprecation-warnings", true, "Warn about deprecated config fields")
+	scrapeCmd.Flags().BoolVar(&fatalDeprecations, "fatal-deprecations", false, "Fail on deprecated config fields, e.g. to check a config in CI before upgrading")
+	manifestValidateCmd.Flags().BoolVar(&deprecationWarnings, "deprecation-warnings", true, "Warn about deprecated config fields")
+	manifestValidateCmd.Flags().BoolVar(&fatalDeprecations, "fatal-deprecations", false, "Fail on deprecated config fields, e.g. to check a config in CI before upgrading")
+}
+
+// checkDeprecations warns about every deprecated field set in the config
+// document, or fails with --fatal-deprecations.
+func checkDeprecations(document map[string]any) error {
+	var errs []error
+	for _, field := range slices.Sorted(maps.Keys(DeprecatedFields)) {
+		deprecation := DeprecatedFields[field]
+		for _, path := range setFieldPaths(document, strings.Split(field, "."), nil) {
+			message := fmt.Sprintf("deprecated field %q will be removed in version %s", path, deprecation.RemovedIn)
+			if deprecation.Replacement != "" {
+				message += fmt.Sprintf("; use %q instead", deprecation.Replacement)
+			}
+			switch {
+			case fatalDeprecations:
+				errs = append(errs, errors.New(message))
+			case deprecationWarnings:
+				warnf("%s", message)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setFieldPaths returns the paths of the fields of value matching path,
+// where * matches every field of an object and every element of a list.
+func setFieldPaths(value any, path, prefix []string) []string {
+	if len(path) == 0 {
+		return []string{strings.Join(prefix, ".")}
+	}
+	var paths []string
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			if path[0] == "*" || path[0] == key {
+				paths = append(paths, setFieldPaths(value[key], path[1:], append(slices.Clone(prefix), key))...)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if index := strconv.Itoa(i); path[0] == "*" || path[0] == index {
+				paths = append(paths, setFieldPaths(item, path[1:], append(slices.Clone(prefix), index))...)
+			}
+		}
+	}
+	return paths
+}
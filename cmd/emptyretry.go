@@ -0,0 +1,110 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var (
+	retryOnEmpty      int
+	retryOnEmptyDelay time.Duration
+)
+
+func init() {
+	scrapeCmd.Flags().IntVar(&retryOnEmpty, "retry-on-empty", 0, "Retry a group metric up to this many times when it drops to 0 from a non-zero value of the previous scrape (uses --state-file)")
+	scrapeCmd.Flags().DurationVar(&retryOnEmptyDelay, "retry-on-empty-delay", 10*time.Second, "Delay between the retries of --retry-on-empty")
+}
+
+// retryEmptyValues wraps the group metrics so that a metric whose values all
+// are 0 while they were not in the previous scrape is collected again, up
+// to --retry-on-empty times. GitLab can briefly report empty results for
+// non-empty groups, e.g. while a replica catches up, which would otherwise
+// be pushed as if every project had been deleted. The retries made are
+// added to retries.
+func retryEmptyValues(definitions []metricDefinition[GroupConfig], retries *int) []metricDefinition[GroupConfig] {
+	wrapped := make([]metricDefinition[GroupConfig], len(definitions))
+	for i, definition := range definitions {
+		collect := definition.Collect
+		definition.Collect = func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			previous := state.groupValues(group.ID)
+			for attempt := 0; ; attempt++ {
+				collectors, err := collect(ctx, git, group, labels)
+				if err != nil {
+					return collectors, err
+				}
+				values, err := collectorSums(collectors)
+				if err != nil {
+					return collectors, err
+				}
+				if attempt == retryOnEmpty || !droppedToZero(previous, values) {
+					state.recordGroupValues(group.ID, values)
+					return collectors, nil
+				}
+
+				fmt.Printf("Metric %s of group %s dropped to 0, retrying in %s\n", definition.Key, group.ID, retryOnEmptyDelay)
+				*retries++
+				select {
+				case <-ctx.Done():
+					return collectors, ctx.Err()
+				case <-time.After(retryOnEmptyDelay):
+				}
+				// The empty result may come from the cached project list,
+				// which would be returned again.
+				evictGroupProjects(group)
+			}
+		}
+		wrapped[i] = definition
+	}
+	return wrapped
+}
+
+// collectorSums returns the sum of the values of every metric name the
+// collectors produce.
+func collectorSums(collectors []prometheus.Collector) (map[string]float64, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register metric: %w", err)
+		}
+	}
+	values, err := gatherValues(registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	sums := map[string]float64{}
+	for _, value := range values {
+		sums[value.Name] += value.Value
+	}
+	return sums, nil
+}
+
+// droppedToZero reports whether every value is 0 while at least one of them
+// was not before.
+func droppedToZero(previous, values map[string]float64) bool {
+	dropped := false
+	for name, value := range values {
+		if value != 0 {
+			return false
+		}
+		dropped = dropped || previous[name] != 0
+	}
+	return dropped
+}
+
+func emptyValueRetriesCounter(labels prometheus.Labels, retries int) prometheus.Counter {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "gitlab_scrape_empty_value_retries_total",
+		Help:        "Number of times a metric of the GitLab group was collected again after it dropped to 0",
+		ConstLabels: labels,
+	})
+	counter.Add(float64(retries))
+	return counter
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestRetryEmptyValuesListsProjectsAgain(t *testing.T) {
+	previousState, previousRetries, previousDelay := state, retryOnEmpty, retryOnEmptyDelay
+	t.Cleanup(func() {
+		state, retryOnEmpty, retryOnEmptyDelay = previousState, previousRetries, previousDelay
+		resetGroupProjectCache()
+	})
+	state = &State{GroupValues: map[string]map[string]float64{"1": {"gitlab_group_project_count": 3}}}
+	retryOnEmpty, retryOnEmptyDelay = 2, 0
+
+	group := GroupConfig{ID: "1"}
+	resetGroupProjectCache()
+	groupProjectCache.projects[group.ID] = &groupProjects{done: make(chan struct{})}
+	close(groupProjectCache.projects[group.ID].done)
+
+	var cached []bool
+	var retries int
+	definitions := retryEmptyValues([]metricDefinition[GroupConfig]{{
+		Key: "project_count",
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			groupProjectCache.Lock()
+			_, ok := groupProjectCache.projects[group.ID]
+			groupProjectCache.Unlock()
+			cached = append(cached, ok)
+
+			count := 0.0
+			if len(cached) > 1 {
+				count = 3
+			}
+			return []prometheus.Collector{newGauge("gitlab_group_project_count", "Number of projects", labels, count)}, nil
+		},
+	}}, &retries)
+
+	collectors, err := definitions[0].Collect(context.Background(), nil, group, prometheus.Labels{"group_id": group.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := collectorSums(collectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["gitlab_group_project_count"] != 3 {
+		t.Errorf("project count = %v, want 3", values["gitlab_group_project_count"])
+	}
+	if retries != 1 {
+		t.Errorf("retries = %d, want 1", retries)
+	}
+	if len(cached) != 2 || !cached[0] || cached[1] {
+		t.Errorf("project list cached per attempt = %v, want [true false]", cached)
+	}
+	if got := state.groupValues(group.ID)["gitlab_group_project_count"]; got != 3 {
+		t.Errorf("recorded project count = %v, want 3", got)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const envPrefix = "GITLAB_SCRAPER_"
+
+// legacyEnvVars are the environment variables that were read before the
+// GITLAB_SCRAPER_ prefix was introduced. They are still read as a fallback.
+var legacyEnvVars = map[string]string{
+	"access_token":     "GITLAB_ACCESS_TOKEN",
+	"push_gateway_url": "PUSHGATEWAY_URL",
+	"gitlab_url":       "GITLAB_URL",
+	"job_name":         "PUSHGATEWAY_JOB",
+}
+
+// envKeys returns the viper keys that can be set through environment
+// variables: the access token and every scalar top level config field.
+func envKeys() []string {
+	keys := []string{"access_token"}
+	configType := reflect.TypeOf(Config{})
+	for i := range configType.NumField() {
+		field := configType.Field(i)
+		switch field.Type.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Struct:
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+func envVarName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// bindEnv binds every env key to its GITLAB_SCRAPER_ variable, falling back
+// to the legacy variable where there is one.
+func bindEnv() {
+	for _, key := range envKeys() {
+		names := []string{key, envVarName(key)}
+		if legacy, ok := legacyEnvVars[key]; ok {
+			names = append(names, legacy)
+		}
+		viper.BindEnv(names...)
+	}
+}
+
+// warnUnknownEnv warns about GITLAB_SCRAPER_ variables that do not belong
+// to any env key, which are most likely typos.
+func warnUnknownEnv() {
+	var known []string
+	for _, key := range envKeys() {
+		known = append(known, envVarName(key))
+	}
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if strings.HasPrefix(name, envPrefix) && !slices.Contains(known, name) {
+			warnf("unknown environment variable %s, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const allEpicStates = "all"
+
+func (c *EpicCountConfig) state() string {
+	if c.State == "" {
+		return allEpicStates
+	}
+	return c.State
+}
+
+type EpicCount struct {
+	Count int
+	// Available is false on instances without epics, which are a GitLab
+	// Premium feature.
+	Available bool
+}
+
+// getGroupEpicCount counts the epics of the group and its subgroups with the
+// configured state. The total is read from the pagination headers, so a
+// single epic is requested.
+func getGroupEpicCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (EpicCount, error) {
+	options := &gitlab.ListGroupEpicsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+	if state := group.EpicCount.state(); state != allEpicStates {
+		options.State = gitlab.Ptr(state)
+	}
+	if withinDays := group.EpicCount.WithinDays; withinDays > 0 {
+		options.CreatedAfter = gitlab.Ptr(time.Now().AddDate(0, 0, -withinDays))
+	}
+
+	_, resp, err := git.Epics.ListGroupEpics(group.ID, options, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return EpicCount{}, nil
+	}
+	if err != nil {
+		return EpicCount{}, fmt.Errorf("failed to list epics for group %s: %w", group.ID, err)
+	}
+	return EpicCount{Count: resp.TotalItems, Available: true}, nil
+}
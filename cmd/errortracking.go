@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type ErrorTrackingCount struct {
+	Issues int
+	// Enabled is false when error tracking is not set up for the project,
+	// in which case it has no issues.
+	Enabled bool
+}
+
+// listErrorTrackingErrorsOptions are the query parameters of the error
+// tracking errors endpoint, which client-go does not implement.
+type listErrorTrackingErrorsOptions struct {
+	gitlab.ListOptions
+	Status *string `url:"status,omitempty"`
+}
+
+// getErrorTrackingCount counts the error tracking issues of the project,
+// only those with the configured status if set. The total is read from the
+// pagination headers, so a single issue is requested.
+func getErrorTrackingCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (ErrorTrackingCount, error) {
+	settings, resp, err := git.ErrorTracking.GetErrorTrackingSettings(project.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return ErrorTrackingCount{}, nil
+	}
+	if err != nil {
+		return ErrorTrackingCount{}, fmt.Errorf("failed to get error tracking settings for project %s: %w", project.ID, err)
+	}
+	if !settings.Active {
+		return ErrorTrackingCount{}, nil
+	}
+
+	opt := &listErrorTrackingErrorsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+	if status := project.ErrorTrackingCount.Status; status != "" {
+		opt.Status = gitlab.Ptr(status)
+	}
+
+	u := fmt.Sprintf("projects/%s/error_tracking/errors", gitlab.PathEscape(project.ID))
+	req, err := git.NewRequest(http.MethodGet, u, opt, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return ErrorTrackingCount{}, fmt.Errorf("failed to create error tracking request for project %s: %w", project.ID, err)
+	}
+
+	resp, err = git.Do(req, nil)
+	if isFeatureUnavailable(resp, err) {
+		return ErrorTrackingCount{}, nil
+	}
+	if err != nil {
+		return ErrorTrackingCount{}, fmt.Errorf("failed to list error tracking issues for project %s: %w", project.ID, err)
+	}
+	return ErrorTrackingCount{Issues: resp.TotalItems, Enabled: true}, nil
+}
+
+type SentryIntegration struct {
+	Enabled bool
+	// Available is false when the token may not read the error tracking
+	// settings of the project.
+	Available bool
+}
+
+// getSentryIntegrationStatus reads whether the project sends its errors to
+// Sentry. GitLab has no Sentry integration among its services, Sentry is
+// instead connected through the error tracking settings, as the backend of
+// error tracking that is not integrated. Projects that never configured
+// error tracking have no settings, which the API reports as not found.
+func getSentryIntegrationStatus(ctx context.Context, git *gitlab.Client, project ProjectConfig) (SentryIntegration, error) {
+	settings, resp, err := git.ErrorTracking.GetErrorTrackingSettings(project.ID, gitlab.WithContext(ctx))
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return SentryIntegration{Available: true}, nil
+	}
+	if isFeatureUnavailable(resp, err) {
+		return SentryIntegration{}, nil
+	}
+	if err != nil {
+		return SentryIntegration{}, fmt.Errorf("failed to get error tracking settings for project %s: %w", project.ID, err)
+	}
+	return SentryIntegration{Enabled: settings.Active && !settings.Integrated, Available: true}, nil
+}
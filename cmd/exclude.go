@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var (
+	// excludePatterns are the compiled global_exclude_patterns of the config
+	// that is being scraped.
+	excludePatterns []*regexp.Regexp
+	// projectsExcluded counts the excluded projects of every scrape since the
+	// start of the process. A project is counted once per scrape, however
+	// many metrics check it.
+	projectsExcluded atomic.Int64
+)
+
+// excludedProjects holds the IDs of the projects excluded in the current
+// scrape. It is reset together with the group project cache.
+var excludedProjects = struct {
+	sync.Mutex
+	ids map[int]struct{}
+}{ids: map[int]struct{}{}}
+
+func resetExcludedProjects() {
+	excludedProjects.Lock()
+	defer excludedProjects.Unlock()
+	excludedProjects.ids = map[int]struct{}{}
+}
+
+// countExcludedProject counts the project unless it was already excluded
+// in the current scrape.
+func countExcludedProject(project *gitlab.Project) {
+	excludedProjects.Lock()
+	defer excludedProjects.Unlock()
+	if _, ok := excludedProjects.ids[project.ID]; ok {
+		return
+	}
+	excludedProjects.ids[project.ID] = struct{}{}
+	projectsExcluded.Add(1)
+}
+
+func compileExcludePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid global exclude pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// isExcludedProject reports whether the path or name of project matches a
+// global exclude pattern. Every function that iterates the projects of a
+// group skips the excluded ones.
+func isExcludedProject(project *gitlab.Project) bool {
+	for _, re := range excludePatterns {
+		if re.MatchString(project.Path) || re.MatchString(project.Name) {
+			countExcludedProject(project)
+			return true
+		}
+	}
+	return false
+}
+
+func projectsExcludedCounter(labels prometheus.Labels) prometheus.Counter {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "gitlab_scrape_projects_excluded_total",
+		Help:        "Number of projects skipped because they match a global exclude pattern",
+		ConstLabels: labels,
+	})
+	counter.Add(float64(projectsExcluded.Load()))
+	return counter
+}
@@ -0,0 +1,68 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var scrapeExplainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Print the GitLab API calls a scrape would make",
+	Long: `This command prints a plan of every GitLab API call a scrape with the provided
+configuration file would make, without contacting GitLab. For each group and project it
+lists the enabled metrics, the endpoints they call, an estimate of the number of calls
+and whether the results are paginated.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		explain(os.Stdout, loadConfig(configFile))
+	},
+}
+
+func init() {
+	scrapeCmd.AddCommand(scrapeExplainCmd)
+	scrapeExplainCmd.Flags().StringVarP(&configFile, "config", "c", "", "config file, or - to read it from stdin (required)")
+	scrapeExplainCmd.MarkFlagRequired("config")
+}
+
+func explain(out io.Writer, config *Config) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tMETRIC\tENDPOINT\tCALLS\tPAGINATED")
+
+	groupIDs, projectIDs := redactedIDs(config)
+	for _, group := range config.Groups {
+		explainTarget(w, "group "+groupIDs[group.ID], groupMetrics, group)
+	}
+	for _, project := range config.Projects {
+		explainTarget(w, "project "+projectIDs[project.ID], projectMetrics, project)
+	}
+	if config.ApplicationStats != nil {
+		fmt.Fprintln(w, "instance\tapplication_stats\tGET /application/statistics\t1\tno")
+	}
+	if config.GroupProjectLanguageMatrix != nil {
+		fmt.Fprintln(w, "instance\tgroup_project_language_matrix\tGET /groups/:id/projects\t1 per 100 projects of every group in the matrix\tyes")
+		fmt.Fprintln(w, "instance\tgroup_project_language_matrix\tGET /projects/:id/languages\t1 per project of every group in the matrix\tno")
+	}
+
+	w.Flush()
+}
+
+func explainTarget[T any](w io.Writer, target string, definitions []metricDefinition[T], config T) {
+	for _, definition := range definitions {
+		if !definition.Enabled(config) {
+			continue
+		}
+		for _, call := range definition.APICalls {
+			paginated := "no"
+			if call.Paginated {
+				paginated = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", target, definition.Key, call.Endpoint, call.Calls, paginated)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const defaultMaxMembersForExternalCheck = 200
+
+func (c *ExternalMemberConfig) maxMembers() int {
+	if c.MaxMembersForExternalCheck <= 0 {
+		return defaultMaxMembersForExternalCheck
+	}
+	return c.MaxMembersForExternalCheck
+}
+
+type ExternalMemberCount struct {
+	External int
+	Internal int
+}
+
+// getExternalMemberCount counts the external and internal members of the
+// group, including inherited members. The member list does not tell external
+// users apart, so every member is looked up on its own, up to
+// max_members_for_external_check of them. GitLab only reports the external
+// flag to administrators, other tokens see every member as internal.
+func getExternalMemberCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (ExternalMemberCount, error) {
+	opt := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var members []*gitlab.GroupMember
+	for {
+		page, resp, err := git.Groups.ListAllGroupMembers(group.ID, opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return ExternalMemberCount{}, fmt.Errorf("failed to list members for group %s: %w", group.ID, err)
+		}
+		members = append(members, page...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if limit := group.MemberExternalCount.maxMembers(); len(members) > limit {
+		warnf("group %s has %d members, only the first %d are checked for external users", group.ID, len(members), limit)
+		members = members[:limit]
+	}
+
+	var count ExternalMemberCount
+	for _, member := range members {
+		user, _, err := git.Users.GetUser(member.ID, gitlab.GetUsersOptions{}, gitlab.WithContext(ctx))
+		if err != nil {
+			return ExternalMemberCount{}, fmt.Errorf("failed to get user %d for group %s: %w", member.ID, group.ID, err)
+		}
+		if user.External {
+			count.External++
+		} else {
+			count.Internal++
+		}
+	}
+	return count, nil
+}
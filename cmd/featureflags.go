@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type FeatureFlagCount struct {
+	Total  int
+	Active int
+	// Strategies counts the strategies of the flags by name, e.g.
+	// gradualRolloutUserId.
+	Strategies map[string]int
+	// Available is false when feature flags are disabled for the project or
+	// the token may not read them.
+	Available bool
+}
+
+// getFeatureFlagCount counts the feature flags of the project, only the
+// active or inactive ones if active is set.
+func getFeatureFlagCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (FeatureFlagCount, error) {
+	opt := &gitlab.ListProjectFeatureFlagOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	if active := project.FeatureFlagCount.Active; active != nil {
+		if *active {
+			opt.Scope = gitlab.Ptr("enabled")
+		} else {
+			opt.Scope = gitlab.Ptr("disabled")
+		}
+	}
+
+	count := FeatureFlagCount{Strategies: map[string]int{}, Available: true}
+	for {
+		flags, resp, err := git.ProjectFeatureFlags.ListProjectFeatureFlags(project.ID, opt, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			return FeatureFlagCount{}, nil
+		}
+		if err != nil {
+			return FeatureFlagCount{}, fmt.Errorf("failed to list feature flags for project %s: %w", project.ID, err)
+		}
+
+		for _, flag := range flags {
+			count.Total++
+			if flag.Active {
+				count.Active++
+			}
+			for _, strategy := range flag.Strategies {
+				count.Strategies[strategy.Name]++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
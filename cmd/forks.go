@@ -0,0 +1,46 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const defaultForkNetworkMaxDepth = 10
+
+func (c *ForkNetworkConfig) maxDepth() int {
+	if c.MaxDepth <= 0 {
+		return defaultForkNetworkMaxDepth
+	}
+	return c.MaxDepth
+}
+
+// getProjectForkNetwork follows the chain of projects the project was forked
+// from and returns its length, 0 for a project that is not a fork. The
+// chain is followed up to max_depth projects, or until a parent is not
+// visible to the token.
+func getProjectForkNetwork(ctx context.Context, git *gitlab.Client, project ProjectConfig) (int, error) {
+	p, _, err := git.Projects.GetProject(project.ID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get project %s: %w", project.ID, err)
+	}
+
+	depth := 0
+	for parent := p.ForkedFromProject; parent != nil && depth < project.ProjectForkNetwork.maxDepth(); {
+		depth++
+		forked, resp, err := git.Projects.GetProject(parent.ID, nil, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			fmt.Printf("Fork parent %d of project %s is not accessible, stopping at depth %d\n", parent.ID, project.ID, depth)
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to get fork parent %d of project %s: %w", parent.ID, project.ID, err)
+		}
+		parent = forked.ForkedFromProject
+	}
+	return depth, nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	healthCheckREADME           = "readme"
+	healthCheckCIConfig         = "ci_config"
+	healthCheckBranchProtection = "branch_protection"
+	healthCheckRecentCommit     = "recent_commit"
+	healthCheckFewOpenIssues    = "few_open_issues"
+)
+
+var healthChecks = []string{healthCheckREADME, healthCheckCIConfig, healthCheckBranchProtection, healthCheckRecentCommit, healthCheckFewOpenIssues}
+
+const (
+	defaultHealthCommitWithinDays = 90
+	defaultHealthMaxOpenIssues    = 50
+)
+
+func (c *HealthConfig) weight(check string) float64 {
+	if weight, ok := c.Weights[check]; ok {
+		return weight
+	}
+	return 1
+}
+
+func (c *HealthConfig) commitWithinDays() int {
+	if c.CommitWithinDays <= 0 {
+		return defaultHealthCommitWithinDays
+	}
+	return c.CommitWithinDays
+}
+
+func (c *HealthConfig) maxOpenIssues() int {
+	if c.MaxOpenIssues <= 0 {
+		return defaultHealthMaxOpenIssues
+	}
+	return c.MaxOpenIssues
+}
+
+func validateRepositoryHealth(projects []ProjectConfig) error {
+	for _, project := range projects {
+		if project.RepositoryHealth == nil {
+			continue
+		}
+		if err := validateCheckWeights("repository_health", project.ID, project.RepositoryHealth.Weights, healthChecks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type RepositoryHealth struct {
+	Score float64
+	// Checks holds whether each health check passed.
+	Checks map[string]bool
+}
+
+// getRepositoryHealth adds up the weights of the health checks the project
+// passes: a README, a CI/CD configuration, a protected default branch, a
+// commit to the default branch within commit_within_days days and fewer
+// than max_open_issues open issues. The default branch provides both the
+// protection and its last commit, so this costs two calls, plus the lookup
+// of .gitlab-ci.yml for projects without a custom ci_config_path.
+func getRepositoryHealth(ctx context.Context, git *gitlab.Client, project ProjectConfig, now time.Time) (RepositoryHealth, error) {
+	config := project.RepositoryHealth
+	p, _, err := git.Projects.GetProject(project.ID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return RepositoryHealth{}, fmt.Errorf("failed to get project %s: %w", project.ID, err)
+	}
+
+	checks := map[string]bool{
+		healthCheckREADME:        p.ReadmeURL != "",
+		healthCheckFewOpenIssues: p.OpenIssuesCount < config.maxOpenIssues(),
+	}
+	// Projects with an empty repository have no default branch.
+	if p.DefaultBranch != "" {
+		branch, _, err := git.Branches.GetBranch(p.ID, p.DefaultBranch, gitlab.WithContext(ctx))
+		if err != nil {
+			return RepositoryHealth{}, fmt.Errorf("failed to get default branch of project %s: %w", project.ID, err)
+		}
+		checks[healthCheckBranchProtection] = branch.Protected
+		cutoff := now.AddDate(0, 0, -config.commitWithinDays())
+		checks[healthCheckRecentCommit] = branch.Commit != nil && branch.Commit.CommittedDate != nil && branch.Commit.CommittedDate.After(cutoff)
+	}
+
+	checks[healthCheckCIConfig], err = hasCIConfig(ctx, git, p)
+	if err != nil {
+		return RepositoryHealth{}, err
+	}
+
+	health := RepositoryHealth{Checks: checks}
+	for _, check := range healthChecks {
+		if checks[check] {
+			health.Score += config.weight(check)
+		}
+	}
+	return health, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	incidentStatusTriggered    = "triggered"
+	incidentStatusAcknowledged = "acknowledged"
+	incidentStatusResolved     = "resolved"
+)
+
+type IncidentCount struct {
+	// ByStatus counts the incidents by escalation status. Closed incidents
+	// are counted as resolved.
+	ByStatus map[string]int
+	// Available is false when the incidents of the group cannot be queried.
+	Available bool
+}
+
+// Open counts the incidents that still need a response.
+func (c IncidentCount) Open() int {
+	return c.ByStatus[incidentStatusTriggered] + c.ByStatus[incidentStatusAcknowledged]
+}
+
+const incidentsQuery = `query($fullPath: ID!, $state: IssuableState!, $after: String) {
+  group(fullPath: $fullPath) {
+    issues(types: [INCIDENT], includeSubgroups: true, state: $state, first: 100, after: $after) {
+      pageInfo {
+        hasNextPage
+        endCursor
+      }
+      nodes {
+        escalationStatus
+        severity
+      }
+    }
+  }
+}`
+
+type incidentsResponse struct {
+	Group *struct {
+		Issues *struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				EscalationStatus *string `json:"escalationStatus"`
+				Severity         string  `json:"severity"`
+			} `json:"nodes"`
+		} `json:"issues"`
+	} `json:"group"`
+}
+
+// getIncidentCount counts the incidents of the group and its subgroups by
+// escalation status. The REST API filters neither by escalation status nor
+// by severity, so open incidents are listed through GraphQL. Closed
+// incidents, of which there are usually many, are counted from the
+// pagination headers of a single REST request unless they have to be
+// filtered by priority.
+func getIncidentCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (IncidentCount, error) {
+	config := group.IncidentCount
+	fullPath, err := groupFullPath(ctx, git, group.ID)
+	if err != nil {
+		return IncidentCount{}, err
+	}
+
+	count := IncidentCount{ByStatus: map[string]int{}, Available: true}
+	ok, err := countIncidents(ctx, git, group, fullPath, "opened", count.ByStatus)
+	if err != nil || !ok {
+		return IncidentCount{}, err
+	}
+
+	if config.Status != "" && config.Status != incidentStatusResolved {
+		return count, nil
+	}
+	if config.Priority != "" {
+		closed := map[string]int{}
+		if ok, err := countIncidents(ctx, git, group, fullPath, "closed", closed); err != nil || !ok {
+			return IncidentCount{}, err
+		}
+		for _, incidents := range closed {
+			count.ByStatus[incidentStatusResolved] += incidents
+		}
+		return count, nil
+	}
+
+	options := &gitlab.ListGroupIssuesOptions{
+		IssueType: gitlab.Ptr("incident"),
+		State:     gitlab.Ptr("closed"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+	_, resp, err := git.Issues.ListGroupIssues(group.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return IncidentCount{}, fmt.Errorf("failed to list closed incidents for group %s: %w", group.ID, err)
+	}
+	count.ByStatus[incidentStatusResolved] += resp.TotalItems
+	return count, nil
+}
+
+// countIncidents adds the incidents with the given state and the configured
+// priority to counts by escalation status. Incidents without one, as on
+// instances without escalations, are triggered. ok is false when the
+// incidents cannot be queried.
+func countIncidents(ctx context.Context, git *gitlab.Client, group GroupConfig, fullPath, state string, counts map[string]int) (ok bool, err error) {
+	variables := map[string]any{"fullPath": fullPath, "state": state}
+	for {
+		var incidents incidentsResponse
+		ok, err := doGraphQL(ctx, git, incidentsQuery, variables, &incidents)
+		if err != nil {
+			return false, fmt.Errorf("failed to list %s incidents for group %s: %w", state, group.ID, err)
+		}
+
+		result := incidents.Group
+		if !ok || result == nil || result.Issues == nil {
+			return false, nil
+		}
+
+		for _, incident := range result.Issues.Nodes {
+			if priority := group.IncidentCount.Priority; priority != "" && !strings.EqualFold(incident.Severity, priority) {
+				continue
+			}
+			status := incidentStatusTriggered
+			if incident.EscalationStatus != nil {
+				status = strings.ToLower(*incident.EscalationStatus)
+			}
+			counts[status]++
+		}
+
+		if !result.Issues.PageInfo.HasNextPage {
+			return true, nil
+		}
+		variables["after"] = result.Issues.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const defaultInsightsMetricName = "gitlab_group_insights_value"
+
+type InsightsValue struct {
+	Dataset string
+	Label   string
+	Value   float64
+}
+
+// insightsChart is the Chart.js shaped payload returned by the Insights
+// query endpoint.
+type insightsChart struct {
+	Labels   []string `json:"labels"`
+	Datasets []struct {
+		Label string            `json:"label"`
+		Data  []json.RawMessage `json:"data"`
+	} `json:"datasets"`
+}
+
+// validateInsightsQueries rejects insights queries that are not valid JSON
+// or that list a label twice in collection_labels or filter_labels, which
+// would push the same series twice.
+func validateInsightsQueries(groups []GroupConfig) error {
+	for _, group := range groups {
+		if group.InsightsQuery == nil {
+			continue
+		}
+		var query any
+		if err := json.Unmarshal([]byte(group.InsightsQuery.Query), &query); err != nil {
+			return fmt.Errorf("insights query for group %s is not valid JSON: %w", group.ID, err)
+		}
+		if label, ok := duplicateInsightsLabel(query); ok {
+			return fmt.Errorf("insights query for group %s lists the label %q more than once", group.ID, label)
+		}
+	}
+	return nil
+}
+
+// duplicateInsightsLabel returns a label listed twice in a collection_labels
+// or filter_labels list anywhere in the query.
+func duplicateInsightsLabel(query any) (string, bool) {
+	switch query := query.(type) {
+	case map[string]any:
+		for key, value := range query {
+			if labels, ok := value.([]any); ok && (key == "collection_labels" || key == "filter_labels") {
+				seen := map[any]bool{}
+				for _, label := range labels {
+					if seen[label] {
+						return fmt.Sprint(label), true
+					}
+					seen[label] = true
+				}
+			}
+			if label, ok := duplicateInsightsLabel(value); ok {
+				return label, true
+			}
+		}
+	case []any:
+		for _, value := range query {
+			if label, ok := duplicateInsightsLabel(value); ok {
+				return label, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (c *InsightsConfig) metricName() string {
+	if c.MetricName == "" {
+		return defaultInsightsMetricName
+	}
+	return c.MetricName
+}
+
+// getInsightsData runs the configured Insights query against the group and
+// flattens the resulting chart into one value per dataset and label. The
+// Insights endpoint is not part of the versioned REST API, so the request is
+// sent to /groups/:full_path/-/insights/query next to the API base URL, which
+// only accepts the full path of the group.
+func getInsightsData(ctx context.Context, git *gitlab.Client, group GroupConfig) ([]InsightsValue, error) {
+	metricName := group.InsightsQuery.metricName()
+	if !model.IsValidMetricName(model.LabelValue(metricName)) {
+		return nil, fmt.Errorf("invalid insights metric name %q for group %s", metricName, group.ID)
+	}
+
+	query := json.RawMessage(group.InsightsQuery.Query)
+	if !json.Valid(query) {
+		return nil, fmt.Errorf("insights query for group %s is not valid JSON", group.ID)
+	}
+
+	req, err := git.NewRequest(http.MethodPost, "", query, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create insights request for group %s: %w", group.ID, err)
+	}
+	fullPath, err := groupFullPath(ctx, git, group.ID)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = insightsQueryURL(git.BaseURL(), fullPath)
+
+	var chart insightsChart
+	if _, err := git.Do(req, &chart); err != nil {
+		return nil, fmt.Errorf("failed to run insights query for group %s: %w", group.ID, err)
+	}
+
+	if len(chart.Datasets) == 0 {
+		fmt.Printf("Insights query for group %s returned no datasets\n", group.ID)
+		return nil, nil
+	}
+
+	var values []InsightsValue
+	for _, dataset := range chart.Datasets {
+		if len(dataset.Data) > len(chart.Labels) {
+			return nil, fmt.Errorf("insights dataset %q for group %s has more values than labels", dataset.Label, group.ID)
+		}
+
+		for i, raw := range dataset.Data {
+			var value *float64
+			if err := json.Unmarshal(raw, &value); err != nil {
+				fmt.Printf("Skipping non-numeric insights value %s in dataset %q for group %s\n", raw, dataset.Label, group.ID)
+				continue
+			}
+			if value == nil {
+				continue
+			}
+
+			values = append(values, InsightsValue{
+				Dataset: dataset.Label,
+				Label:   chart.Labels[i],
+				Value:   *value,
+			})
+		}
+	}
+
+	return values, nil
+}
+
+func insightsQueryURL(baseURL *url.URL, fullPath string) *url.URL {
+	u := *baseURL
+	root := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v4")
+	u.Path = root + "/groups/" + fullPath + "/-/insights/query"
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// getServiceIntegrationCount counts the active integrations of the project
+// by type, e.g. jira or slack. If integration_type is set, only that type is
+// counted.
+func getServiceIntegrationCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (map[string]int, error) {
+	services, _, err := git.Services.ListServices(project.ID, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list integrations for project %s: %w", project.ID, err)
+	}
+
+	counts := map[string]int{}
+	for _, service := range services {
+		if !service.Active {
+			continue
+		}
+		if filter := project.ServiceIntegrationCount.IntegrationType; filter != "" && service.Slug != filter {
+			continue
+		}
+		counts[service.Slug]++
+	}
+	return counts, nil
+}
+
+// chatIntegrationTypes are the integrations that post notifications to a
+// chat service.
+var chatIntegrationTypes = []string{"slack", "microsoft-teams", "mattermost", "hangouts-chat", "discord", "webex-teams", "telegram", "pumble", "unify-circuit"}
+
+// getChatNotificationCount counts the active chat integrations of every
+// project in the group and its subgroups. Integrations are only listed per
+// project, so this costs one call per project.
+func getChatNotificationCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for _, project := range projects {
+		services, _, err := git.Services.ListServices(project.ID, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list integrations for project %s: %w", strconv.Itoa(project.ID), err)
+		}
+		for _, service := range services {
+			if service.Active && slices.Contains(chatIntegrationTypes, service.Slug) {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+type JiraIntegration struct {
+	Enabled bool
+	// ProjectKeys are the keys of the Jira projects the integration is
+	// restricted to, if any.
+	ProjectKeys []string
+	// Available is false when the token may not read the integrations of
+	// the project, which requires the Maintainer role.
+	Available bool
+}
+
+// getJiraIntegrationStatus reads whether the Jira integration of the project
+// is active. Projects that never set up the integration get a not found
+// response on older GitLab versions.
+func getJiraIntegrationStatus(ctx context.Context, git *gitlab.Client, project ProjectConfig) (JiraIntegration, error) {
+	jira, resp, err := git.Services.GetJiraService(project.ID, gitlab.WithContext(ctx))
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return JiraIntegration{Available: true}, nil
+	}
+	if isFeatureUnavailable(resp, err) {
+		return JiraIntegration{}, nil
+	}
+	if err != nil {
+		return JiraIntegration{}, fmt.Errorf("failed to get Jira integration for project %s: %w", project.ID, err)
+	}
+	if !jira.Active {
+		return JiraIntegration{Available: true}, nil
+	}
+
+	integration := JiraIntegration{Enabled: true, Available: true}
+	if properties := jira.Properties; properties != nil {
+		integration.ProjectKeys = properties.ProjectKeys
+		// GitLab before 17.0 has a single project key.
+		if len(integration.ProjectKeys) == 0 && properties.ProjectKey != "" {
+			integration.ProjectKeys = []string{properties.ProjectKey}
+		}
+	}
+	return integration, nil
+}
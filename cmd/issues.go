@@ -0,0 +1,67 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type IssueSLABreaches struct {
+	Overdue int
+	AtRisk  int
+	// HealthStatusAvailable is only set on GitLab EE, which is the only
+	// edition that reports a health status on issues.
+	HealthStatusAvailable bool
+}
+
+func getIssueSLABreaches(ctx context.Context, git *gitlab.Client, group GroupConfig) (IssueSLABreaches, error) {
+	deadline := time.Now().AddDate(0, 0, -group.IssueSLABreaches.DaysOverdue)
+
+	options := &gitlab.ListGroupIssuesOptions{
+		State:   gitlab.Ptr("opened"),
+		DueDate: gitlab.Ptr("overdue"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var breaches IssueSLABreaches
+	for {
+		issues, resp, err := git.Issues.ListGroupIssues(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return breaches, fmt.Errorf("failed to list overdue issues for group %s: %w", group.ID, err)
+		}
+
+		for _, issue := range issues {
+			if issue.DueDate == nil {
+				continue
+			}
+			// An issue is due until the end of its due date, so an issue
+			// due today is not overdue yet.
+			if time.Time(*issue.DueDate).AddDate(0, 0, 1).After(deadline) {
+				continue
+			}
+			breaches.Overdue++
+
+			if issue.HealthStatus != "" {
+				breaches.HealthStatusAvailable = true
+				if issue.HealthStatus == "at_risk" {
+					breaches.AtRisk++
+				}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return breaches, nil
+}
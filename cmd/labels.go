@@ -0,0 +1,198 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// validateFieldLabels rejects labels_from_group_fields whose label name is
+// invalid or would replace group_id, a default_labels or extra_labels entry
+// or another field label of the group.
+func validateFieldLabels(config *Config) error {
+	for _, group := range config.Groups {
+		seen := map[string]bool{}
+		for _, field := range group.LabelsFromGroupFields {
+			name := field.LabelName
+			_, isDefault := config.DefaultLabels[name]
+			_, isExtra := group.ExtraLabels[name]
+			switch {
+			case !model.LabelName(name).IsValid():
+				return fmt.Errorf("invalid label name %q for field %s of group %s", name, field.Field, group.ID)
+			case name == "group_id":
+				return fmt.Errorf("label name group_id for field %s of group %s is reserved", field.Field, group.ID)
+			case isDefault:
+				return fmt.Errorf("label name %s for field %s of group %s is already a default label", name, field.Field, group.ID)
+			case isExtra:
+				return fmt.Errorf("label name %s for field %s of group %s is already an extra label of the group", name, field.Field, group.ID)
+			case seen[name]:
+				return fmt.Errorf("label name %s is used for more than one field of group %s", name, group.ID)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// getGroupFieldLabels fetches the group and turns the configured fields of
+// the API response into labels. Fields are addressed with a dotted path into
+// the JSON representation of the group, e.g. ".visibility" or
+// ".shared_runners_setting".
+func getGroupFieldLabels(ctx context.Context, git *gitlab.Client, group GroupConfig) (prometheus.Labels, error) {
+	details, _, err := git.Groups.GetGroup(group.ID, &gitlab.GetGroupOptions{
+		WithProjects: gitlab.Ptr(false),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group %s: %w", group.ID, err)
+	}
+
+	document, err := toJSONDocument(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode group %s: %w", group.ID, err)
+	}
+
+	labels := prometheus.Labels{}
+	for _, field := range group.LabelsFromGroupFields {
+		if !model.LabelName(field.LabelName).IsValid() {
+			return nil, fmt.Errorf("invalid label name %q for field %s of group %s", field.LabelName, field.Field, group.ID)
+		}
+
+		value, ok := lookupJSONPath(document, field.Field)
+		if !ok {
+			fmt.Printf("Field %s not found on group %s\n", field.Field, group.ID)
+		}
+		labels[field.LabelName] = value
+	}
+	return labels, nil
+}
+
+func toJSONDocument(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document any
+	err = decoder.Decode(&document)
+	return document, err
+}
+
+// lookupJSONPath resolves a dotted path like ".a.b" in a decoded JSON
+// document and coerces the value to a string. Objects and arrays are
+// rendered as JSON.
+func lookupJSONPath(document any, path string) (string, bool) {
+	current := document
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if current, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch value := current.(type) {
+	case nil:
+		return "", true
+	case string:
+		return value, true
+	case json.Number, bool:
+		return fmt.Sprint(value), true
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
+
+// aliasPrefix marks an extra_labels key as a reference to an alias, e.g.
+// {"$platform": ""} expands to the labels of the "platform" alias.
+const aliasPrefix = "$"
+
+// expandAliases replaces alias references in the extra labels of every group
+// with the labels of the alias. Alias values are JSON encoded label maps that
+// may reference other aliases themselves. Labels set directly on the group
+// take precedence over labels from an alias.
+func expandAliases(config *Config) error {
+	resolved := map[string]map[string]string{}
+
+	var resolve func(name string, stack []string) (map[string]string, error)
+	resolve = func(name string, stack []string) (map[string]string, error) {
+		if labels, ok := resolved[name]; ok {
+			return labels, nil
+		}
+		if slices.Contains(stack, name) {
+			return nil, fmt.Errorf("circular alias reference %s", strings.Join(append(stack, name), " -> "))
+		}
+
+		encoded, ok := config.Aliases[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown alias %q", name)
+		}
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(encoded), &labels); err != nil {
+			return nil, fmt.Errorf("alias %q is not a JSON encoded label map: %w", name, err)
+		}
+
+		expanded, err := expandLabelAliases(labels, func(ref string) (map[string]string, error) {
+			return resolve(ref, append(slices.Clone(stack), name))
+		})
+		if err != nil {
+			return nil, err
+		}
+		resolved[name] = expanded
+		return expanded, nil
+	}
+
+	for i := range config.Groups {
+		expanded, err := expandLabelAliases(config.Groups[i].ExtraLabels, func(ref string) (map[string]string, error) {
+			return resolve(ref, nil)
+		})
+		if err != nil {
+			return fmt.Errorf("group %s: %w", config.Groups[i].ID, err)
+		}
+		config.Groups[i].ExtraLabels = expanded
+	}
+	return nil
+}
+
+func expandLabelAliases(labels map[string]string, resolve func(string) (map[string]string, error)) (map[string]string, error) {
+	if len(labels) == 0 {
+		return labels, nil
+	}
+
+	expanded := map[string]string{}
+	direct := map[string]string{}
+	for key, value := range labels {
+		name, isAlias := strings.CutPrefix(key, aliasPrefix)
+		if !isAlias {
+			direct[key] = value
+			continue
+		}
+
+		aliasLabels, err := resolve(name)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(expanded, aliasLabels)
+	}
+	maps.Copy(expanded, direct)
+	return expanded, nil
+}
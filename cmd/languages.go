@@ -0,0 +1,145 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	defaultLanguageMatrixMaxGroups    = 20
+	defaultLanguageMatrixMaxLanguages = 10
+	defaultLanguageMatrixMaxSeries    = 200
+)
+
+func (c *LanguageMatrixConfig) maxGroups() int {
+	if c.MaxGroups <= 0 {
+		return defaultLanguageMatrixMaxGroups
+	}
+	return c.MaxGroups
+}
+
+func (c *LanguageMatrixConfig) maxLanguages() int {
+	if c.MaxLanguages <= 0 {
+		return defaultLanguageMatrixMaxLanguages
+	}
+	return c.MaxLanguages
+}
+
+func (c *LanguageMatrixConfig) maxSeries() int {
+	if c.MaxSeries <= 0 {
+		return defaultLanguageMatrixMaxSeries
+	}
+	return c.MaxSeries
+}
+
+// validateLanguageMatrix rejects a matrix that could push more than
+// max_series series, which is the number of groups it covers times
+// max_languages.
+func validateLanguageMatrix(config *Config) error {
+	matrix := config.GroupProjectLanguageMatrix
+	if matrix == nil {
+		return nil
+	}
+	groups := min(len(config.Groups), matrix.maxGroups())
+	if series := groups * matrix.maxLanguages(); series > matrix.maxSeries() {
+		return fmt.Errorf("group_project_language_matrix may push up to %d series for %d groups and max_languages %d, more than max_series %d",
+			series, groups, matrix.maxLanguages(), matrix.maxSeries())
+	}
+	return nil
+}
+
+// getGroupLanguageBytes estimates the bytes of every language in the
+// projects of the group and its subgroups. GitLab only reports the share of
+// each language in a repository, so the bytes are that share of the
+// repository size, which makes them an estimate that includes the history
+// of the repository. The project list includes the statistics, which
+// requires the Reporter role.
+func getGroupLanguageBytes(ctx context.Context, git *gitlab.Client, group GroupConfig) (map[string]float64, error) {
+	u := fmt.Sprintf("groups/%s/projects", gitlab.PathEscape(group.ID))
+	opt := &groupProjectStatisticsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+		IncludeSubGroups: gitlab.Ptr(true),
+		Statistics:       gitlab.Ptr(true),
+	}
+
+	languages := map[string]float64{}
+	for {
+		req, err := git.NewRequest(http.MethodGet, u, opt, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create project list request for group %s: %w", group.ID, err)
+		}
+
+		var projects []*gitlab.Project
+		resp, err := git.Do(req, &projects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project statistics for group %s: %w", group.ID, err)
+		}
+
+		for _, project := range projects {
+			if project.Statistics == nil || project.Statistics.RepositorySize == 0 || isExcludedProject(project) {
+				continue
+			}
+			shares, _, err := git.Projects.GetProjectLanguages(project.ID, gitlab.WithContext(ctx))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get languages of project %s: %w", project.PathWithNamespace, err)
+			}
+			for language, percent := range *shares {
+				languages[language] += float64(percent) / 100 * float64(project.Statistics.RepositorySize)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return languages, nil
+}
+
+// collectLanguageMatrix pushes the language bytes of the first max_groups
+// configured groups, the max_languages largest languages of each.
+func collectLanguageMatrix(ctx context.Context, git *gitlab.Client, config *Config) ([]prometheus.Collector, error) {
+	matrix := config.GroupProjectLanguageMatrix
+	groups := config.Groups
+	if len(groups) > matrix.maxGroups() {
+		fmt.Printf("%d groups are configured, only the first %d are part of the language matrix\n", len(groups), matrix.maxGroups())
+		groups = groups[:matrix.maxGroups()]
+	}
+
+	matrixGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name:        "gitlab_language_matrix_bytes",
+		Help:        "Estimated bytes of the language in the projects of the GitLab group",
+		ConstLabels: config.DefaultLabels,
+	}, []string{"group_id", "language"})
+	for _, group := range groups {
+		if ctx.Err() != nil {
+			break
+		}
+		languages, err := getGroupLanguageBytes(ctx, git, group)
+		if err != nil {
+			return []prometheus.Collector{matrixGauge}, err
+		}
+
+		names := slices.SortedFunc(maps.Keys(languages), func(a, b string) int {
+			return cmp.Or(cmp.Compare(languages[b], languages[a]), cmp.Compare(a, b))
+		})
+		for _, language := range names[:min(matrix.maxLanguages(), len(names))] {
+			fmt.Printf("Bytes of %s in group %s: %.0f\n", language, group.ID, languages[language])
+			matrixGauge.WithLabelValues(group.ID, language).Set(languages[language])
+		}
+	}
+	return []prometheus.Collector{matrixGauge}, nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logFormatText   = "text"
+	logFormatJSON   = "json"
+	logFormatLogfmt = "logfmt"
+)
+
+var logFormats = []string{logFormatText, logFormatJSON, logFormatLogfmt}
+
+var logFormat string
+
+func init() {
+	scrapeCmd.Flags().StringVar(&logFormat, "log-format", logFormatText, "Format of the --log-api-responses log messages, which it requires: "+strings.Join(logFormats, ", "))
+}
+
+// validateLogFormat checks --log-format, which is set if changed. Only the
+// --log-api-responses messages are structured, the rest of the output is
+// plain text, so the flag is rejected without it rather than silently having
+// no effect.
+func validateLogFormat(changed bool) error {
+	if !slices.Contains(logFormats, logFormat) {
+		return fmt.Errorf("unknown log format %q, expected one of %s", logFormat, strings.Join(logFormats, ", "))
+	}
+	if changed && !logAPIResponses {
+		return errors.New("--log-format only applies to --log-api-responses, which is not set")
+	}
+	return nil
+}
+
+func newLogHandler(w io.Writer, options *slog.HandlerOptions) slog.Handler {
+	switch logFormat {
+	case logFormatJSON:
+		return slog.NewJSONHandler(w, options)
+	case logFormatLogfmt:
+		return newLogfmtHandler(w, options)
+	default:
+		return slog.NewTextHandler(w, options)
+	}
+}
+
+// logfmtHandler writes records as logfmt lines, e.g.
+// time=2006-01-02T15:04:05Z level=info msg="GitLab API response" status=200.
+// Unlike slog.TextHandler, times are in UTC with second precision and
+// levels are lower case, as most logfmt consumers expect.
+type logfmtHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Leveler
+	prefix string
+	preset []byte
+}
+
+// newLogfmtHandler supports the Level of options, the other options are
+// ignored.
+func newLogfmtHandler(w io.Writer, options *slog.HandlerOptions) *logfmtHandler {
+	var level slog.Leveler = slog.LevelInfo
+	if options != nil && options.Level != nil {
+		level = options.Level
+	}
+	return &logfmtHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *logfmtHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *logfmtHandler) Handle(_ context.Context, record slog.Record) error {
+	line := []byte{}
+	if !record.Time.IsZero() {
+		line = appendLogfmtPair(line, slog.TimeKey, record.Time.UTC().Format(time.RFC3339))
+	}
+	line = appendLogfmtPair(line, slog.LevelKey, strings.ToLower(record.Level.String()))
+	line = appendLogfmtPair(line, slog.MessageKey, record.Message)
+	if len(h.preset) > 0 {
+		line = append(append(line, ' '), h.preset...)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		line = h.appendAttr(line, h.prefix, attr)
+		return true
+	})
+	line = append(line, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(line)
+	return err
+}
+
+func (h *logfmtHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.preset = slices.Clone(h.preset)
+	for _, attr := range attrs {
+		clone.preset = h.appendAttr(clone.preset, h.prefix, attr)
+	}
+	return &clone
+}
+
+func (h *logfmtHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr appends attr with its key prefixed by the names of the groups
+// it is in, flattening group attributes the same way.
+func (h *logfmtHandler) appendAttr(line []byte, prefix string, attr slog.Attr) []byte {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return line
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			line = h.appendAttr(line, prefix, member)
+		}
+		return line
+	}
+
+	var value string
+	switch attr.Value.Kind() {
+	case slog.KindTime:
+		value = attr.Value.Time().UTC().Format(time.RFC3339)
+	default:
+		value = attr.Value.String()
+	}
+	return appendLogfmtPair(line, prefix+attr.Key, value)
+}
+
+func appendLogfmtPair(line []byte, key, value string) []byte {
+	if len(line) > 0 {
+		line = append(line, ' ')
+	}
+	line = append(line, logfmtKey(key)...)
+	line = append(line, '=')
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.ContainsFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) {
+		return strconv.AppendQuote(line, value)
+	}
+	return append(line, value...)
+}
+
+// logfmtKey drops the characters logfmt does not allow in keys.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, key)
+}
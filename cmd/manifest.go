@@ -0,0 +1,180 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/spf13/cobra"
+)
+
+// highCardinalityThreshold is the number of distinct values of a label above
+// which manifest validate warns about cardinality.
+const highCardinalityThreshold = 100
+
+// baseUnits are the unit suffixes Prometheus recommends for metric names.
+var baseUnits = []string{"seconds", "bytes", "ratio", "percent", "total", "count", "info", "celsius", "meters", "volts", "amperes", "joules", "grams"}
+
+// reservedMetricPrefixes are used by the Go client, Prometheus itself and the
+// Push Gateway.
+var reservedMetricPrefixes = []string{"go_", "process_", "promhttp_", "prometheus_", "pushgateway_", "scrape_"}
+
+var reservedMetricNames = []string{"up", "push_time_seconds", "push_failure_time_seconds"}
+
+// highCardinalityLabels are label names whose values are usually unbounded.
+var highCardinalityLabels = []string{"project_name", "project_path", "username", "user", "email", "sha", "commit", "url", "path"}
+
+type manifestReport struct {
+	errors   []string
+	warnings []string
+}
+
+func (r *manifestReport) errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *manifestReport) warnf(format string, args ...any) {
+	r.warnings = append(r.warnings, fmt.Sprintf(format, args...))
+}
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Inspect the metrics a config file produces",
+}
+
+var manifestValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate metric names and labels against Prometheus best practices",
+	Long: `This command checks the metric names and labels the configuration file would produce
+against Prometheus naming conventions without contacting GitLab. It exits with a non-zero
+status if any violation is found; warnings alone do not fail the command.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		report := validateManifest(loadConfig(configFile))
+
+		for _, warning := range report.warnings {
+			fmt.Printf("warning: %s\n", warning)
+		}
+		for _, err := range report.errors {
+			fmt.Printf("error: %s\n", err)
+		}
+
+		if len(report.errors) > 0 {
+			os.Exit(1)
+		}
+		fmt.Printf("Manifest is valid (%d warnings)\n", len(report.warnings))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(manifestCmd)
+	manifestCmd.AddCommand(manifestValidateCmd)
+	manifestValidateCmd.Flags().StringVarP(&configFile, "config", "c", "", "config file, or - to read it from stdin (required)")
+	manifestValidateCmd.MarkFlagRequired("config")
+}
+
+func validateManifest(config *Config) *manifestReport {
+	report := &manifestReport{}
+
+	for name := range config.DefaultLabels {
+		validateLabelName(report, name, "default_labels")
+	}
+
+	// series counts how many targets push each metric name, which is the
+	// number of distinct group_id or project_id values it ends up with.
+	series := map[string]int{}
+	seriesLabel := map[string]string{}
+
+	for i, group := range config.Groups {
+		for name := range group.ExtraLabels {
+			validateLabelName(report, name, fmt.Sprintf("groups[%d].extra_labels", i))
+		}
+		for _, field := range group.LabelsFromGroupFields {
+			validateLabelName(report, field.LabelName, fmt.Sprintf("groups[%d].labels_from_group_fields", i))
+		}
+
+		for _, definition := range groupMetrics {
+			if !definition.Enabled(group) {
+				continue
+			}
+			location := fmt.Sprintf("groups[%d].%s", i, definition.Key)
+			validateMetricDefinition(report, definition.names(group), definition.Labels, location)
+			for _, name := range definition.names(group) {
+				series[name]++
+				seriesLabel[name] = "group_id"
+			}
+		}
+
+		if group.InsightsQuery != nil && group.InsightsQuery.Unit != "" {
+			validateMetricUnit(report, group.InsightsQuery.metricName(), group.InsightsQuery.Unit, fmt.Sprintf("groups[%d].insights_query", i))
+		}
+	}
+
+	for i, project := range config.Projects {
+		for _, definition := range projectMetrics {
+			if !definition.Enabled(project) {
+				continue
+			}
+			location := fmt.Sprintf("projects[%d].%s", i, definition.Key)
+			validateMetricDefinition(report, definition.names(project), definition.Labels, location)
+			for _, name := range definition.names(project) {
+				series[name]++
+				seriesLabel[name] = "project_id"
+			}
+		}
+	}
+
+	for name, count := range series {
+		if count > highCardinalityThreshold {
+			report.warnf("metric %q is pushed with %d distinct %s values", name, count, seriesLabel[name])
+		}
+	}
+
+	slices.Sort(report.errors)
+	slices.Sort(report.warnings)
+	return report
+}
+
+func validateMetricDefinition(report *manifestReport, names, labels []string, location string) {
+	for _, name := range names {
+		if !model.IsValidMetricName(model.LabelValue(name)) {
+			report.errorf("%s: %q is not a valid metric name", location, name)
+			continue
+		}
+		if slices.Contains(reservedMetricNames, name) || slices.ContainsFunc(reservedMetricPrefixes, func(prefix string) bool {
+			return strings.HasPrefix(name, prefix)
+		}) {
+			report.warnf("%s: metric %q shadows a standard Prometheus, Go or Push Gateway metric", location, name)
+		}
+	}
+
+	for _, label := range labels {
+		validateLabelName(report, label, location)
+		if slices.Contains(highCardinalityLabels, label) {
+			report.warnf("%s: label %q usually has a high number of distinct values", location, label)
+		}
+	}
+}
+
+func validateLabelName(report *manifestReport, name, location string) {
+	if !model.LabelName(name).IsValid() {
+		report.errorf("%s: %q is not a valid label name", location, name)
+	}
+	if strings.HasPrefix(name, model.ReservedLabelPrefix) {
+		report.errorf("%s: label %q uses the reserved %q prefix", location, name, model.ReservedLabelPrefix)
+	}
+}
+
+func validateMetricUnit(report *manifestReport, name, unit, location string) {
+	if !slices.Contains(baseUnits, unit) {
+		report.errorf("%s: %q is not a Prometheus base unit, use one of %s", location, unit, strings.Join(baseUnits, ", "))
+		return
+	}
+	if !strings.HasSuffix(name, "_"+unit) {
+		report.errorf("%s: metric %q must end with the unit suffix %q", location, name, "_"+unit)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var accessLevelRoles = map[gitlab.AccessLevelValue]string{
+	gitlab.MinimalAccessPermissions: "minimal_access",
+	gitlab.GuestPermissions:         "guest",
+	gitlab.ReporterPermissions:      "reporter",
+	gitlab.DeveloperPermissions:     "developer",
+	gitlab.MaintainerPermissions:    "maintainer",
+	gitlab.OwnerPermissions:         "owner",
+}
+
+func accessLevelRole(level gitlab.AccessLevelValue) string {
+	if role, ok := accessLevelRoles[level]; ok {
+		return role
+	}
+	return strconv.Itoa(int(level))
+}
+
+// getMemberCountsByRole counts the members of the group by role, keyed by an
+// empty role without by_role. Only the direct members of the group itself
+// are counted by role.
+func getMemberCountsByRole(ctx context.Context, git *gitlab.Client, group GroupConfig) (map[string]int, error) {
+	if !group.MembershipChange.ByRole {
+		count, err := getGroupMembersCount(ctx, git, group)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]int{"": count}, nil
+	}
+
+	options := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	counts := map[string]int{}
+	for {
+		members, resp, err := git.Groups.ListGroupMembers(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members for group %s: %w", group.ID, err)
+		}
+
+		for _, member := range members {
+			counts[accessLevelRole(member.AccessLevel)]++
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return counts, nil
+}
+
+func roleSuffix(role string) string {
+	if role == "" {
+		return ""
+	}
+	return " with role " + role
+}
+
+// getGroupOwnerCount counts the owners of the group, including the ones
+// inherited from parent groups, who have the same privileges. The members
+// API cannot filter by access level, so every member is listed.
+func getGroupOwnerCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
+	options := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	owners := 0
+	for {
+		members, resp, err := git.Groups.ListAllGroupMembers(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list members for group %s: %w", group.ID, err)
+		}
+
+		for _, member := range members {
+			if member.AccessLevel == gitlab.OwnerPermissions {
+				owners++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return owners, nil
+}
+
+type BillableMemberCount struct {
+	Count int
+	// Available is false on instances without seat based billing, such as
+	// self-managed GitLab CE.
+	Available bool
+}
+
+// getBillableMemberCount counts the members of the top-level group that take
+// up a paid seat, which excludes bots and, on the Ultimate plan, guests. The
+// total is read from the pagination headers, so a single member is
+// requested.
+func getBillableMemberCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (BillableMemberCount, error) {
+	options := &gitlab.ListBillableGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+
+	_, resp, err := git.Groups.ListBillableGroupMembers(group.ID, options, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return BillableMemberCount{}, nil
+	}
+	if err != nil {
+		return BillableMemberCount{}, fmt.Errorf("failed to list billable members for group %s: %w", group.ID, err)
+	}
+	return BillableMemberCount{Count: resp.TotalItems, Available: true}, nil
+}
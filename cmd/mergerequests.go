@@ -0,0 +1,371 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	defaultMRSizeState         = "merged"
+	defaultMRSizeWindowDays    = 30
+	defaultMaxMRsToScanForSize = 20
+)
+
+func (c *MRSizeConfig) state() string {
+	if c.State == "" {
+		return defaultMRSizeState
+	}
+	return c.State
+}
+
+func (c *MRSizeConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultMRSizeWindowDays
+	}
+	return c.WindowDays
+}
+
+func (c *MRSizeConfig) maxMRsToScan() int {
+	if c.MaxMRsToScan <= 0 {
+		return defaultMaxMRsToScanForSize
+	}
+	return c.MaxMRsToScan
+}
+
+// listGroupMergeRequests lists up to limit merge requests of the group,
+// following the pagination since GitLab returns at most 100 per page. more
+// reports whether the group has merge requests beyond the limit.
+func listGroupMergeRequests(ctx context.Context, git *gitlab.Client, groupID string, options *gitlab.ListGroupMergeRequestsOptions, limit int) (mergeRequests []*gitlab.MergeRequest, more bool, err error) {
+	options.Page = 1
+	for {
+		options.PerPage = min(limit-len(mergeRequests), 100)
+		page, resp, err := git.MergeRequests.ListGroupMergeRequests(groupID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, false, err
+		}
+		mergeRequests = append(mergeRequests, page...)
+
+		if resp.NextPage == 0 {
+			return mergeRequests, false, nil
+		}
+		if len(mergeRequests) >= limit {
+			return mergeRequests, true, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+type MRSizeStats struct {
+	AverageChanges float64
+	Scanned        int
+}
+
+// getMRSizeStats averages the changes_count of the most recently updated
+// merge requests of the group. The merge request list does not include the
+// changes, so every merge request costs an additional API call and only the
+// last max_mrs_to_scan are inspected.
+func getMRSizeStats(ctx context.Context, git *gitlab.Client, group GroupConfig) (MRSizeStats, error) {
+	config := group.MergeRequestSize
+	options := &gitlab.ListGroupMergeRequestsOptions{
+		State:        gitlab.Ptr(config.state()),
+		UpdatedAfter: gitlab.Ptr(time.Now().AddDate(0, 0, -config.windowDays())),
+		OrderBy:      gitlab.Ptr("updated_at"),
+		Sort:         gitlab.Ptr("desc"),
+	}
+
+	mergeRequests, _, err := listGroupMergeRequests(ctx, git, group.ID, options, config.maxMRsToScan())
+	if err != nil {
+		return MRSizeStats{}, fmt.Errorf("failed to list merge requests for group %s: %w", group.ID, err)
+	}
+
+	var (
+		stats MRSizeStats
+		total int
+	)
+	for _, listed := range mergeRequests {
+		mr, _, err := git.MergeRequests.GetMergeRequest(listed.ProjectID, listed.IID, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return MRSizeStats{}, fmt.Errorf("failed to get merge request !%d of project %d: %w", listed.IID, listed.ProjectID, err)
+		}
+		// GitLab caps the reported changes, e.g. at 1000+.
+		changes, err := strconv.Atoi(strings.TrimSuffix(mr.ChangesCount, "+"))
+		if err != nil {
+			continue
+		}
+		total += changes
+		stats.Scanned++
+	}
+
+	if stats.Scanned > 0 {
+		stats.AverageChanges = float64(total) / float64(stats.Scanned)
+	}
+	return stats, nil
+}
+
+const (
+	defaultMRDiscussionWindowDays = 30
+	defaultMaxMRsToScanForReviews = 20
+)
+
+func (c *MRDiscussionConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultMRDiscussionWindowDays
+	}
+	return c.WindowDays
+}
+
+func (c *MRDiscussionConfig) maxMRsToScan() int {
+	if c.MaxMRsToScan <= 0 {
+		return defaultMaxMRsToScanForReviews
+	}
+	return c.MaxMRsToScan
+}
+
+type MRDiscussionCount struct {
+	Discussions int
+	Unresolved  int
+}
+
+// getMRDiscussionCount counts the discussions on the recently updated merge
+// requests of the group. Only the last max_mrs_to_scan merge requests are
+// inspected, since every one of them costs at least one API call. The
+// resolved filter restricts the discussion count to resolvable discussions
+// in that state, the unresolved count always covers every merge request.
+func getMRDiscussionCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (MRDiscussionCount, error) {
+	config := group.MergeRequestDiscussionCount
+	options := &gitlab.ListGroupMergeRequestsOptions{
+		UpdatedAfter: gitlab.Ptr(time.Now().AddDate(0, 0, -config.windowDays())),
+		OrderBy:      gitlab.Ptr("updated_at"),
+		Sort:         gitlab.Ptr("desc"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: config.maxMRsToScan(),
+		},
+	}
+
+	mergeRequests, resp, err := git.MergeRequests.ListGroupMergeRequests(group.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return MRDiscussionCount{}, fmt.Errorf("failed to list merge requests for group %s: %w", group.ID, err)
+	}
+	if resp.NextPage != 0 {
+		fmt.Printf("Group %s has more merge requests updated in the last %d days, only the last %d are scanned for discussions\n",
+			group.ID, config.windowDays(), config.maxMRsToScan())
+	}
+
+	var count MRDiscussionCount
+	for _, mr := range mergeRequests {
+		discussionOptions := &gitlab.ListMergeRequestDiscussionsOptions{Page: 1, PerPage: 100}
+		for {
+			discussions, resp, err := git.Discussions.ListMergeRequestDiscussions(mr.ProjectID, mr.IID, discussionOptions, gitlab.WithContext(ctx))
+			if err != nil {
+				return MRDiscussionCount{}, fmt.Errorf("failed to list discussions of merge request !%d of project %d: %w", mr.IID, mr.ProjectID, err)
+			}
+
+			for _, discussion := range discussions {
+				resolvable, resolved := discussionState(discussion)
+				if resolvable && !resolved {
+					count.Unresolved++
+				}
+				if config.Resolved == nil || (resolvable && resolved == *config.Resolved) {
+					count.Discussions++
+				}
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			discussionOptions.Page = resp.NextPage
+		}
+	}
+	return count, nil
+}
+
+// discussionState reports whether the discussion can be resolved and, if
+// so, whether all of its resolvable notes are resolved.
+func discussionState(discussion *gitlab.Discussion) (resolvable, resolved bool) {
+	resolved = true
+	for _, note := range discussion.Notes {
+		if !note.Resolvable {
+			continue
+		}
+		resolvable = true
+		resolved = resolved && note.Resolved
+	}
+	return resolvable, resolvable && resolved
+}
+
+const (
+	defaultApprovalBreakdownWindowDays = 30
+	defaultMaxMRsToScanForApprovals    = 20
+)
+
+func (c *ApprovalBreakdownConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultApprovalBreakdownWindowDays
+	}
+	return c.WindowDays
+}
+
+func (c *ApprovalBreakdownConfig) maxMRsToScan() int {
+	if c.MaxMRsToScan <= 0 {
+		return defaultMaxMRsToScanForApprovals
+	}
+	return c.MaxMRsToScan
+}
+
+type ApprovalBreakdown struct {
+	Scanned  int
+	Bypassed int
+	// Available is false on instances without approval rules, which are a
+	// GitLab Premium feature.
+	Available bool
+}
+
+// getApprovalBreakdown counts the recently merged merge requests of the
+// group that were merged while approvals were still missing. Approvals are
+// kept after the merge, so the approval state of a merged merge request is
+// the one it was merged with. Every merge request costs two API calls, so
+// only the last max_mrs_to_scan are inspected.
+func getApprovalBreakdown(ctx context.Context, git *gitlab.Client, group GroupConfig) (ApprovalBreakdown, error) {
+	config := group.ApprovalRuleBreakdown
+	options := &gitlab.ListGroupMergeRequestsOptions{
+		State:        gitlab.Ptr("merged"),
+		UpdatedAfter: gitlab.Ptr(time.Now().AddDate(0, 0, -config.windowDays())),
+		OrderBy:      gitlab.Ptr("updated_at"),
+		Sort:         gitlab.Ptr("desc"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: config.maxMRsToScan(),
+		},
+	}
+
+	mergeRequests, resp, err := git.MergeRequests.ListGroupMergeRequests(group.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return ApprovalBreakdown{}, fmt.Errorf("failed to list merged merge requests for group %s: %w", group.ID, err)
+	}
+	if resp.NextPage != 0 {
+		fmt.Printf("Group %s has more merge requests merged in the last %d days, only the last %d are scanned for approvals\n",
+			group.ID, config.windowDays(), config.maxMRsToScan())
+	}
+
+	breakdown := ApprovalBreakdown{Available: true}
+	for _, mr := range mergeRequests {
+		approvals, resp, err := git.MergeRequestApprovals.GetConfiguration(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			return ApprovalBreakdown{}, nil
+		}
+		if err != nil {
+			return ApprovalBreakdown{}, fmt.Errorf("failed to get approvals of merge request !%d of project %d: %w", mr.IID, mr.ProjectID, err)
+		}
+
+		state, resp, err := git.MergeRequestApprovals.GetApprovalState(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			return ApprovalBreakdown{}, nil
+		}
+		if err != nil {
+			return ApprovalBreakdown{}, fmt.Errorf("failed to get approval state of merge request !%d of project %d: %w", mr.IID, mr.ProjectID, err)
+		}
+
+		breakdown.Scanned++
+		if approvals.ApprovalsLeft > 0 || slices.ContainsFunc(state.Rules, func(rule *gitlab.MergeRequestApprovalRule) bool { return !rule.Approved }) {
+			breakdown.Bypassed++
+		}
+	}
+	return breakdown, nil
+}
+
+const (
+	defaultApprovalWaitWindowDays           = 30
+	defaultMaxMRsToScanForApprovalWaitTimes = 50
+)
+
+func (c *ApprovalWaitConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultApprovalWaitWindowDays
+	}
+	return c.WindowDays
+}
+
+func (c *ApprovalWaitConfig) maxMRsToScan() int {
+	if c.MaxMRsToScan <= 0 {
+		return defaultMaxMRsToScanForApprovalWaitTimes
+	}
+	return c.MaxMRsToScan
+}
+
+type ApprovalWaitTimes struct {
+	// Waiting holds how long every open merge request still missing
+	// approvals has been open, in seconds.
+	Waiting []float64
+	// Skipped is true when the group has more open merge requests than
+	// max_mrs_to_scan, which are not scanned.
+	Skipped bool
+	// Available is false on instances without merge request approvals,
+	// where the approvals of every scanned merge request are unavailable.
+	Available bool
+}
+
+// getApprovalWaitTimes returns how long the open merge requests of the
+// group that still miss approvals have been waiting. Drafts are not
+// waiting for approval and are left out. Every merge request costs an API
+// call, so nothing is scanned if more than max_mrs_to_scan merge requests
+// are open, rather than reporting the wait times of an arbitrary subset.
+func getApprovalWaitTimes(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (ApprovalWaitTimes, error) {
+	config := group.MRApprovalWaitTime
+	options := &gitlab.ListGroupMergeRequestsOptions{
+		State:        gitlab.Ptr("opened"),
+		WIP:          gitlab.Ptr("no"),
+		CreatedAfter: gitlab.Ptr(now.AddDate(0, 0, -config.windowDays())),
+	}
+
+	mergeRequests, more, err := listGroupMergeRequests(ctx, git, group.ID, options, config.maxMRsToScan())
+	if err != nil {
+		return ApprovalWaitTimes{}, fmt.Errorf("failed to list open merge requests for group %s: %w", group.ID, err)
+	}
+	if more {
+		return ApprovalWaitTimes{Skipped: true, Available: true}, nil
+	}
+
+	var (
+		waitTimes   ApprovalWaitTimes
+		unavailable int
+	)
+	for _, mr := range mergeRequests {
+		approvals, resp, err := git.MergeRequestApprovals.GetConfiguration(mr.ProjectID, mr.IID, gitlab.WithContext(ctx))
+		// A single merge request may have been deleted or become
+		// inaccessible since it was listed.
+		if isFeatureUnavailable(resp, err) {
+			unavailable++
+			continue
+		}
+		if err != nil {
+			return ApprovalWaitTimes{}, fmt.Errorf("failed to get approvals of merge request !%d of project %d: %w", mr.IID, mr.ProjectID, err)
+		}
+		if approvals.ApprovalsLeft > 0 && mr.CreatedAt != nil {
+			waitTimes.Waiting = append(waitTimes.Waiting, now.Sub(*mr.CreatedAt).Seconds())
+		}
+	}
+	waitTimes.Available = len(mergeRequests) == 0 || unavailable < len(mergeRequests)
+	return waitTimes, nil
+}
+
+// percentile returns the p-th percentile of values by the nearest-rank
+// method, or 0 if there are none.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,1858 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// metricDefinition describes a metric that can be enabled on a group or a
+// project in the config. Everything that reasons about a config without
+// talking to GitLab works off these definitions.
+type metricDefinition[T any] struct {
+	// Key is the config key that enables the metric.
+	Key string
+	// Names lists every metric name the collector may push.
+	Names []string
+	// NamesFor overrides Names for metrics whose name comes from the config.
+	NamesFor func(target T) []string
+	// Features lists the features the collector reports through
+	// gitlab_scrape_feature_unavailable when they are not available.
+	Features []string
+	// Labels lists the variable labels added on top of the target labels.
+	Labels []string
+	// APICalls describes the GitLab API requests the collector makes.
+	APICalls []apiCall
+	Enabled  func(target T) bool
+	Collect  func(ctx context.Context, git *gitlab.Client, target T, labels prometheus.Labels) ([]prometheus.Collector, error)
+}
+
+type apiCall struct {
+	Endpoint string
+	// Calls is a human readable estimate of how often the endpoint is called.
+	Calls     string
+	Paginated bool
+}
+
+func (d metricDefinition[T]) names(target T) []string {
+	if d.NamesFor != nil {
+		return d.NamesFor(target)
+	}
+	return d.Names
+}
+
+// metricsFilter holds the --metrics-filter globs. A metric definition is only
+// collected when at least one of its metric names matches one of them.
+var metricsFilter []string
+
+func validateMetricsFilter() error {
+	for _, pattern := range metricsFilter {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid metrics filter %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func matchesMetricsFilter(names []string) bool {
+	if len(metricsFilter) == 0 {
+		return true
+	}
+	for _, name := range names {
+		for _, pattern := range metricsFilter {
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateDisabledMetrics(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid disable_metrics pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func isMetricDisabled(disabled []string, name string) bool {
+	for _, pattern := range disabled {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// allMetricsDisabled reports whether every name of a metric definition is
+// disabled, so that it need not be collected. Disabled names of a definition
+// that is still collected are dropped when gathering.
+func allMetricsDisabled(disabled []string, names []string) bool {
+	if len(disabled) == 0 {
+		return false
+	}
+	for _, name := range names {
+		if !isMetricDisabled(disabled, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isFeatureUnavailable reports whether a request failed because the endpoint
+// is not available to the token or on this GitLab edition, which GitLab
+// signals with 403 or 404. Metrics backed by such endpoints degrade to the
+// feature unavailable metric instead of failing the scrape.
+func isFeatureUnavailable(resp *gitlab.Response, err error) bool {
+	if err == nil || resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound
+}
+
+// featureUnavailable is the gauge pushed for a feature that is not
+// available. Several metrics of a target can depend on the same feature, so
+// collectMetrics keeps only the first one for every feature.
+type featureUnavailable struct {
+	prometheus.Gauge
+	feature string
+}
+
+func featureUnavailableGauge(feature string, labels prometheus.Labels) featureUnavailable {
+	return featureUnavailable{
+		Gauge: newGauge("gitlab_scrape_feature_unavailable", "Set when a GitLab feature could not be scraped because it is not available",
+			mergeLabels(labels, prometheus.Labels{"feature": feature}), 1),
+		feature: feature,
+	}
+}
+
+// collectMetrics collects every enabled metric of target that is not
+// disabled. On error the collectors gathered so far are returned alongside
+// it, so that a timed out target can still push what it managed to collect.
+func collectMetrics[T any](ctx context.Context, definitions []metricDefinition[T], git *gitlab.Client, target T, labels prometheus.Labels, disabled []string) ([]prometheus.Collector, error) {
+	var collectors []prometheus.Collector
+	unavailable := map[string]bool{}
+	for _, definition := range definitions {
+		// NamesFor may read the config of the metric, which is nil unless
+		// the metric is enabled.
+		if !definition.Enabled(target) {
+			continue
+		}
+		names := definition.names(target)
+		if !matchesMetricsFilter(names) || allMetricsDisabled(disabled, names) {
+			continue
+		}
+		collected, err := definition.Collect(ctx, git, target, labels)
+		if err != nil {
+			return collectors, err
+		}
+		for _, collector := range collected {
+			if gauge, ok := collector.(featureUnavailable); ok {
+				if unavailable[gauge.feature] {
+					continue
+				}
+				unavailable[gauge.feature] = true
+			}
+			collectors = append(collectors, collector)
+		}
+	}
+	return collectors, nil
+}
+
+var groupMetrics = []metricDefinition[GroupConfig]{
+	{
+		Key:   "project_count",
+		Names: []string{"gitlab_group_project_count", "gitlab_group_popular_project_count"},
+		NamesFor: func(group GroupConfig) []string {
+			if group.ProjectCount.MinStars > 0 {
+				return []string{"gitlab_group_project_count", "gitlab_group_popular_project_count"}
+			}
+			return []string{"gitlab_group_project_count"}
+		},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/projects", Calls: "1, plus 1 per 100 projects with at least min_stars stars", Paginated: true}},
+		Enabled:  func(group GroupConfig) bool { return group.ProjectCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			projectCount, err := getProjectCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Project count in group %s: %d\n", group.ID, projectCount)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_project_count", "Number of projects in the GitLab group", labels, float64(projectCount)),
+			}
+			if minStars := group.ProjectCount.MinStars; minStars > 0 {
+				popular, err := getPopularProjectCount(ctx, git, group)
+				if err != nil {
+					return nil, err
+				}
+				fmt.Printf("Projects with at least %d stars in group %s: %d\n", minStars, group.ID, popular)
+				collectors = append(collectors, newGauge("gitlab_group_popular_project_count", "Number of projects in the GitLab group with at least min_stars stars", mergeLabels(labels, prometheus.Labels{"min_stars": strconv.Itoa(minStars)}), float64(popular)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "project_count_growth_rate",
+		Names:    []string{"gitlab_group_project_count_weekly_growth"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/projects", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.ProjectCountGrowthRate != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			projectCount, err := getProjectCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+
+			window := time.Duration(group.ProjectCountGrowthRate.windowDays()) * 24 * time.Hour
+			growth, ok := state.recordProjectCount(group.ID, projectCount, time.Now(), window)
+			if !ok {
+				fmt.Printf("No project count of group %s from %d days ago yet, reporting no growth\n", group.ID, group.ProjectCountGrowthRate.windowDays())
+			}
+			fmt.Printf("Project count growth in group %s: %d\n", group.ID, growth)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_project_count_weekly_growth", "Change of the number of projects in the GitLab group over the growth rate window", labels, float64(growth)),
+			}, nil
+		},
+	},
+	{
+		Key:      "member_count",
+		Names:    []string{"gitlab_group_members_count"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/members", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.MemberCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			groupMembersCount, err := getGroupMembersCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Group members count in group %s: %d\n", group.ID, groupMembersCount)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_members_count", "Number of members in the GitLab group", labels, float64(groupMembersCount)),
+			}, nil
+		},
+	},
+	{
+		Key:      "issue_sla_breaches",
+		Names:    []string{"gitlab_group_overdue_issues_count", "gitlab_group_at_risk_issues_count"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/issues", Calls: "1 per 100 overdue issues", Paginated: true}},
+		Enabled:  func(group GroupConfig) bool { return group.IssueSLABreaches != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			breaches, err := getIssueSLABreaches(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Overdue issues in group %s: %d\n", group.ID, breaches.Overdue)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_overdue_issues_count", "Number of open issues in the GitLab group that are past their due date", labels, float64(breaches.Overdue)),
+			}
+			if breaches.HealthStatusAvailable {
+				collectors = append(collectors, newGauge("gitlab_group_at_risk_issues_count", "Number of overdue open issues in the GitLab group with health status at risk", labels, float64(breaches.AtRisk)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:   "group_statistics",
+		Names: []string{"gitlab_group_repository_size_bytes", "gitlab_group_lfs_objects_size_bytes", "gitlab_group_job_artifacts_size_bytes", "gitlab_group_packages_size_bytes"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/statistics", Calls: "1"},
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects if the statistics endpoint returns 403", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.GroupStatistics != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			stats, err := getGroupStatistics(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Repository size of group %s: %d bytes\n", group.ID, stats.RepositorySize)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_repository_size_bytes", "Size of all repositories in the GitLab group in bytes", labels, float64(stats.RepositorySize)),
+				newGauge("gitlab_group_lfs_objects_size_bytes", "Size of all LFS objects in the GitLab group in bytes", labels, float64(stats.LFSObjectsSize)),
+				newGauge("gitlab_group_job_artifacts_size_bytes", "Size of all job artifacts in the GitLab group in bytes", labels, float64(stats.JobArtifactsSize)),
+				newGauge("gitlab_group_packages_size_bytes", "Size of all packages in the GitLab group in bytes", labels, float64(stats.PackagesSize)),
+			}, nil
+		},
+	},
+	{
+		Key: "insights_query",
+		NamesFor: func(group GroupConfig) []string {
+			return []string{group.InsightsQuery.metricName()}
+		},
+		Labels: []string{"dataset", "label"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id", Calls: "1 if the group is configured by numeric ID"},
+			{Endpoint: "POST /groups/:full_path/-/insights/query", Calls: "1"},
+		},
+		Enabled: func(group GroupConfig) bool { return group.InsightsQuery != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			metricName := group.InsightsQuery.metricName()
+			values, err := getInsightsData(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Insights query in group %s returned %d values\n", group.ID, len(values))
+
+			var collectors []prometheus.Collector
+			for _, value := range values {
+				valueLabels := mergeLabels(labels, prometheus.Labels{"dataset": value.Dataset, "label": value.Label})
+				collectors = append(collectors, newGauge(metricName, "Value returned by a GitLab Insights query for the group", valueLabels, value.Value))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:   "pipeline_avg_duration",
+		Names: []string{"gitlab_group_pipeline_avg_duration_seconds"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+			{Endpoint: "GET /projects/:id/pipelines", Calls: "1 per project"},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id", Calls: fmt.Sprintf("up to %d per project", maxPipelineDurationsToInspect)},
+		},
+		Enabled: func(group GroupConfig) bool { return group.PipelineAvgDuration != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			duration, pipelines, err := getGroupPipelineDuration(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if pipelines == 0 {
+				fmt.Printf("No finished %s pipelines in group %s\n", group.PipelineAvgDuration.status(), group.ID)
+				return nil, nil
+			}
+			fmt.Printf("Average pipeline duration in group %s (%s over projects): %.0fs over %d pipelines\n", group.ID, group.PipelineAvgDuration.aggregationFunc(), duration, pipelines)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_pipeline_avg_duration_seconds", "Average pipeline duration of the projects in the GitLab group in seconds, combined with the configured aggregation function", labels, duration),
+			}, nil
+		},
+	},
+	{
+		Key:   "merge_request_size",
+		Names: []string{"gitlab_group_mr_avg_lines_changed", "gitlab_scrape_mr_size_scan_count"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/merge_requests", Calls: "1 per 100 merge requests, up to max_mrs_to_scan", Paginated: true},
+			{Endpoint: "GET /projects/:id/merge_requests/:merge_request_iid", Calls: fmt.Sprintf("up to max_mrs_to_scan, %d by default", defaultMaxMRsToScanForSize)},
+		},
+		Enabled: func(group GroupConfig) bool { return group.MergeRequestSize != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			stats, err := getMRSizeStats(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Average merge request size in group %s: %.1f changes over %d merge requests\n", group.ID, stats.AverageChanges, stats.Scanned)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_mr_avg_lines_changed", "Average changes_count of recent merge requests in the GitLab group", labels, stats.AverageChanges),
+				newGauge("gitlab_scrape_mr_size_scan_count", "Number of merge requests the average merge request size of the GitLab group is based on", labels, float64(stats.Scanned)),
+			}, nil
+		},
+	},
+	{
+		Key:   "chat_notification_count",
+		Names: []string{"gitlab_group_active_chat_integration_count"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+			{Endpoint: "GET /projects/:id/services", Calls: "1 per project"},
+		},
+		Enabled: func(group GroupConfig) bool { return group.ChatNotificationCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getChatNotificationCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Active chat integrations in group %s: %d\n", group.ID, count)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_active_chat_integration_count", "Number of active chat notification integrations in the projects of the GitLab group", labels, float64(count)),
+			}, nil
+		},
+	},
+	{
+		Key:      "dependency_proxy_size",
+		Names:    []string{"gitlab_group_dependency_proxy_size_bytes", "gitlab_group_dependency_proxy_blobs_count"},
+		Features: []string{"dependency_proxy"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id", Calls: "1 if the group is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1"},
+		},
+		Enabled: func(group GroupConfig) bool { return group.DependencyProxySize != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			size, err := getDependencyProxySize(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !size.Available {
+				fmt.Printf("Dependency Proxy is not available for group %s\n", group.ID)
+			} else {
+				fmt.Printf("Dependency Proxy of group %s: %d bytes in %d blobs\n", group.ID, size.Bytes, size.Blobs)
+			}
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_dependency_proxy_size_bytes", "Size of the Dependency Proxy cache of the GitLab group in bytes", labels, float64(size.Bytes)),
+				newGauge("gitlab_group_dependency_proxy_blobs_count", "Number of blobs in the Dependency Proxy cache of the GitLab group", labels, float64(size.Blobs)),
+			}
+			if !size.Available {
+				collectors = append(collectors, featureUnavailableGauge("dependency_proxy", labels))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "bot_member_count",
+		Names:    []string{"gitlab_group_bot_member_count", "gitlab_group_human_member_count"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/members/all", Calls: "1 per 100 members", Paginated: true}},
+		Enabled:  func(group GroupConfig) bool { return group.BotMemberCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getBotMemberCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Members of group %s: %d bots, %d humans\n", group.ID, count.Bots, count.Humans)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_bot_member_count", "Number of bot members of the GitLab group, including inherited members", labels, float64(count.Bots)),
+				newGauge("gitlab_group_human_member_count", "Number of human members of the GitLab group, including inherited members", labels, float64(count.Humans)),
+			}, nil
+		},
+	},
+	{
+		Key:   "merge_request_discussion_count",
+		Names: []string{"gitlab_group_mr_discussion_count", "gitlab_group_mr_unresolved_discussion_count"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/merge_requests", Calls: "1"},
+			{Endpoint: "GET /projects/:id/merge_requests/:merge_request_iid/discussions", Calls: fmt.Sprintf("1 per 100 discussions of up to max_mrs_to_scan merge requests, %d by default", defaultMaxMRsToScanForReviews), Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.MergeRequestDiscussionCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getMRDiscussionCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Merge request discussions in group %s: %d, %d unresolved\n", group.ID, count.Discussions, count.Unresolved)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_mr_discussion_count", "Number of discussions on recently updated merge requests in the GitLab group", labels, float64(count.Discussions)),
+				newGauge("gitlab_group_mr_unresolved_discussion_count", "Number of unresolved discussions on recently updated merge requests in the GitLab group", labels, float64(count.Unresolved)),
+			}, nil
+		},
+	},
+	{
+		Key:    "membership_change",
+		Names:  []string{"gitlab_group_member_added_total", "gitlab_group_member_removed_total"},
+		Labels: []string{"role"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/members", Calls: "1, or 1 per 100 members with by_role", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.MembershipChange != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			counts, err := getMemberCountsByRole(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			changes := state.recordMemberCounts(group.ID, counts)
+
+			var labelNames []string
+			if group.MembershipChange.ByRole {
+				labelNames = []string{"role"}
+			}
+			added := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name:        "gitlab_group_member_added_total",
+				Help:        "Number of members added to the GitLab group, derived from the member count between scrapes",
+				ConstLabels: labels,
+			}, labelNames)
+			removed := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name:        "gitlab_group_member_removed_total",
+				Help:        "Number of members removed from the GitLab group, derived from the member count between scrapes",
+				ConstLabels: labels,
+			}, labelNames)
+			for role, change := range changes {
+				var values []string
+				if group.MembershipChange.ByRole {
+					values = []string{role}
+				}
+				fmt.Printf("Member changes in group %s%s: %d added, %d removed\n", group.ID, roleSuffix(role), change.Added, change.Removed)
+				added.WithLabelValues(values...).Add(float64(change.Added))
+				removed.WithLabelValues(values...).Add(float64(change.Removed))
+			}
+
+			return []prometheus.Collector{added, removed}, nil
+		},
+	},
+	{
+		Key:      "count_shared_projects",
+		Names:    []string{"gitlab_group_shared_project_count"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/projects", Calls: "2"}},
+		Enabled:  func(group GroupConfig) bool { return group.CountSharedProjects != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getSharedProjectCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Projects shared with group %s: %d\n", group.ID, count)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_shared_project_count", "Number of projects from other namespaces shared with the GitLab group", labels, float64(count)),
+			}, nil
+		},
+	},
+	{
+		Key:    "project_topic_count",
+		Names:  []string{"gitlab_group_project_topic_count", "gitlab_group_project_no_topic_count"},
+		Labels: []string{"topic"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.ProjectTopicCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			topics, withoutTopics, err := getProjectTopicCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+
+			topicGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_project_topic_count",
+				Help:        "Number of projects in the GitLab group using the topic",
+				ConstLabels: labels,
+			}, []string{"topic"})
+			for _, topic := range topics {
+				fmt.Printf("Projects in group %s with topic %s: %d\n", group.ID, topic.Topic, topic.Projects)
+				topicGauge.WithLabelValues(topic.Topic).Set(float64(topic.Projects))
+			}
+
+			collectors := []prometheus.Collector{topicGauge}
+			if group.ProjectTopicCount.NoTopicCount {
+				fmt.Printf("Projects in group %s without topics: %d\n", group.ID, withoutTopics)
+				collectors = append(collectors, newGauge("gitlab_group_project_no_topic_count", "Number of projects in the GitLab group without topics", labels, float64(withoutTopics)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:   "readme_presence",
+		Names: []string{"gitlab_group_projects_with_readme_count", "gitlab_group_projects_without_readme_count", "gitlab_group_readme_coverage_ratio"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.READMEPresence != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			presence, err := getREADMEPresenceCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Projects in group %s: %d with README, %d without\n", group.ID, presence.With, presence.Without)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_projects_with_readme_count", "Number of projects in the GitLab group with a README", labels, float64(presence.With)),
+				newGauge("gitlab_group_projects_without_readme_count", "Number of projects in the GitLab group without a README", labels, float64(presence.Without)),
+			}
+			// A group without projects has no coverage to report.
+			if total := presence.With + presence.Without; total > 0 {
+				collectors = append(collectors, newGauge("gitlab_group_readme_coverage_ratio", "Share of the projects in the GitLab group with a README", labels, float64(presence.With)/float64(total)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:   "ci_config_presence",
+		Names: []string{"gitlab_group_projects_with_ci_count", "gitlab_group_projects_without_ci_count", "gitlab_group_ci_adoption_ratio"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+			{Endpoint: "HEAD /projects/:id/repository/files/.gitlab-ci.yml", Calls: "1 per project without a custom ci_config_path"},
+		},
+		Enabled: func(group GroupConfig) bool { return group.CIConfigPresence != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			presence, err := getCIConfigPresenceCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Projects in group %s: %d with CI/CD configuration, %d without\n", group.ID, presence.With, presence.Without)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_projects_with_ci_count", "Number of projects in the GitLab group with a CI/CD configuration", labels, float64(presence.With)),
+				newGauge("gitlab_group_projects_without_ci_count", "Number of projects in the GitLab group without a CI/CD configuration", labels, float64(presence.Without)),
+			}
+			if total := presence.With + presence.Without; total > 0 {
+				collectors = append(collectors, newGauge("gitlab_group_ci_adoption_ratio", "Share of the projects in the GitLab group with a CI/CD configuration", labels, float64(presence.With)/float64(total)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "last_activity_age",
+		Names:    []string{"gitlab_group_last_activity_age_seconds"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/projects", Calls: "1, more if the most recently active projects are excluded"}},
+		Enabled:  func(group GroupConfig) bool { return group.LastActivityAge != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			age, err := getGroupLastActivityAge(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Last activity in group %s: %.0fs ago\n", group.ID, age)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_last_activity_age_seconds", "Seconds since the most recently active project of the GitLab group was active, +Inf without projects", labels, age),
+			}, nil
+		},
+	},
+	{
+		Key:   "member_external_count",
+		Names: []string{"gitlab_group_external_member_count", "gitlab_group_internal_member_count"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/members/all", Calls: "1 per 100 members", Paginated: true},
+			{Endpoint: "GET /users/:id", Calls: fmt.Sprintf("1 per member, up to max_members_for_external_check, %d by default", defaultMaxMembersForExternalCheck)},
+		},
+		Enabled: func(group GroupConfig) bool { return group.MemberExternalCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getExternalMemberCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Members of group %s: %d external, %d internal\n", group.ID, count.External, count.Internal)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_external_member_count", "Number of external members of the GitLab group, including inherited members", labels, float64(count.External)),
+				newGauge("gitlab_group_internal_member_count", "Number of internal members of the GitLab group, including inherited members", labels, float64(count.Internal)),
+			}, nil
+		},
+	},
+	{
+		Key:      "epic_count",
+		Names:    []string{"gitlab_group_epic_count"},
+		Features: []string{"epics"},
+		Labels:   []string{"state"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/epics", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.EpicCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getGroupEpicCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			state := group.EpicCount.state()
+			if count.Available {
+				fmt.Printf("Epics in group %s with state %s: %d\n", group.ID, state, count.Count)
+			} else {
+				fmt.Printf("Epics are not available for group %s\n", group.ID)
+			}
+
+			epicCountGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_epic_count",
+				Help:        "Number of epics of the GitLab group and its subgroups by state",
+				ConstLabels: labels,
+			}, []string{"state"})
+			epicCountGauge.WithLabelValues(state).Set(float64(count.Count))
+
+			collectors := []prometheus.Collector{epicCountGauge}
+			if !count.Available {
+				collectors = append(collectors, featureUnavailableGauge("epics", labels))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:    "project_inactivity_alert",
+		Names:  []string{"gitlab_group_inactive_project_count", "gitlab_group_inactive_project_ratio", "gitlab_group_inactive_project_age_seconds"},
+		Labels: []string{"project_name"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.ProjectInactivityAlert != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			inactive, err := getInactiveProjectCount(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Projects in group %s inactive for %d days: %d of %d\n", group.ID, group.ProjectInactivityAlert.inactiveDays(), len(inactive.Projects), inactive.Total)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_group_inactive_project_count", "Number of projects in the GitLab group without activity for inactive_days", labels, float64(len(inactive.Projects))),
+			}
+			if inactive.Total > 0 {
+				collectors = append(collectors, newGauge("gitlab_group_inactive_project_ratio", "Share of the projects in the GitLab group without activity for inactive_days", labels, float64(len(inactive.Projects))/float64(inactive.Total)))
+			}
+			if maxListed := group.ProjectInactivityAlert.MaxListed; maxListed > 0 {
+				ageGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+					Name:        "gitlab_group_inactive_project_age_seconds",
+					Help:        "Seconds since the last activity of the longest inactive projects of the GitLab group",
+					ConstLabels: labels,
+				}, []string{"project_name"})
+				for _, project := range inactive.Projects[:min(maxListed, len(inactive.Projects))] {
+					ageGauge.WithLabelValues(project.Name).Set(project.Age)
+				}
+				collectors = append(collectors, ageGauge)
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "watcher_count",
+		Names:    []string{"gitlab_group_watcher_count", "gitlab_group_not_watchable"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/notification_settings", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.WatcherCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			status, err := getGroupSubscriptionStatus(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !status.Available {
+				fmt.Printf("Notification settings are not available for group %s\n", group.ID)
+				return []prometheus.Collector{
+					newGauge("gitlab_group_not_watchable", "Set when the notification settings of the GitLab group cannot be read", labels, 1),
+				}, nil
+			}
+
+			watchers := 0
+			if status.Watching {
+				watchers = 1
+			}
+			fmt.Printf("Watchers of group %s visible to the token: %d\n", group.ID, watchers)
+			return []prometheus.Collector{
+				newGauge("gitlab_group_watcher_count", "Number of watchers of the GitLab group visible to the scraper, which is only the user of the token", labels, float64(watchers)),
+			}, nil
+		},
+	},
+	{
+		Key:      "approval_rule_breakdown",
+		Names:    []string{"gitlab_group_mr_bypassed_approvals_count"},
+		Features: []string{"merge_request_approvals"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/merge_requests", Calls: "1"},
+			{Endpoint: "GET /projects/:id/merge_requests/:merge_request_iid/approvals", Calls: fmt.Sprintf("1 per merge request, up to max_mrs_to_scan, %d by default", defaultMaxMRsToScanForApprovals)},
+			{Endpoint: "GET /projects/:id/merge_requests/:merge_request_iid/approval_state", Calls: fmt.Sprintf("1 per merge request, up to max_mrs_to_scan, %d by default", defaultMaxMRsToScanForApprovals)},
+		},
+		Enabled: func(group GroupConfig) bool { return group.ApprovalRuleBreakdown != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			breakdown, err := getApprovalBreakdown(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !breakdown.Available {
+				fmt.Printf("Merge request approvals are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("merge_request_approvals", labels)}, nil
+			}
+			fmt.Printf("Merged merge requests in group %s with missing approvals: %d of %d\n", group.ID, breakdown.Bypassed, breakdown.Scanned)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_mr_bypassed_approvals_count", "Number of recently merged merge requests in the GitLab group that were merged with approvals missing", labels, float64(breakdown.Bypassed)),
+			}, nil
+		},
+	},
+	{
+		Key:      "mr_approval_wait_time",
+		Names:    []string{"gitlab_group_mr_approval_wait_avg_seconds", "gitlab_group_mr_approval_wait_p90_seconds"},
+		Features: []string{"merge_request_approvals"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/merge_requests", Calls: "1 per 100 open merge requests, up to max_mrs_to_scan", Paginated: true},
+			{Endpoint: "GET /projects/:id/merge_requests/:merge_request_iid/approvals", Calls: fmt.Sprintf("1 per open merge request, none if there are more than max_mrs_to_scan, %d by default", defaultMaxMRsToScanForApprovalWaitTimes)},
+		},
+		Enabled: func(group GroupConfig) bool { return group.MRApprovalWaitTime != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			waitTimes, err := getApprovalWaitTimes(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			if !waitTimes.Available {
+				fmt.Printf("Merge request approvals are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("merge_request_approvals", labels)}, nil
+			}
+			if waitTimes.Skipped {
+				fmt.Printf("Group %s has more than %d open merge requests created in the last %d days, skipping approval wait times\n",
+					group.ID, group.MRApprovalWaitTime.maxMRsToScan(), group.MRApprovalWaitTime.windowDays())
+				return nil, nil
+			}
+
+			average := 0.0
+			for _, waiting := range waitTimes.Waiting {
+				average += waiting
+			}
+			if len(waitTimes.Waiting) > 0 {
+				average /= float64(len(waitTimes.Waiting))
+			}
+			p90 := percentile(waitTimes.Waiting, 90)
+			fmt.Printf("Open merge requests in group %s waiting for approval: %d, %.0fs on average, %.0fs at p90\n", group.ID, len(waitTimes.Waiting), average, p90)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_mr_approval_wait_avg_seconds", "Average time the open merge requests of the GitLab group that miss approvals have been open in seconds", labels, average),
+				newGauge("gitlab_group_mr_approval_wait_p90_seconds", "90th percentile of the time the open merge requests of the GitLab group that miss approvals have been open in seconds", labels, p90),
+			}, nil
+		},
+	},
+	{
+		Key:      "owner_count",
+		Names:    []string{"gitlab_group_owner_count"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/members/all", Calls: "1 per 100 members", Paginated: true}},
+		Enabled:  func(group GroupConfig) bool { return group.OwnerCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			owners, err := getGroupOwnerCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Owners of group %s: %d\n", group.ID, owners)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_owner_count", "Number of owners of the GitLab group, including inherited owners", labels, float64(owners)),
+			}, nil
+		},
+	},
+	{
+		Key:    "failed_job_count",
+		Names:  []string{"gitlab_group_failed_job_count"},
+		Labels: []string{"failure_reason"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+			{Endpoint: "GET /projects/:id/jobs", Calls: "1 per project, plus 1 per 100 failed jobs within within_hours", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.FailedJobCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			reasons, err := getGroupFailedJobCount(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+
+			failedJobGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_failed_job_count",
+				Help:        "Number of recently failed jobs in the projects of the GitLab group by failure reason",
+				ConstLabels: labels,
+			}, []string{"failure_reason"})
+			for reason, count := range reasons {
+				fmt.Printf("Failed jobs in group %s with reason %s: %d\n", group.ID, reason, count)
+				failedJobGauge.WithLabelValues(reason).Set(float64(count))
+			}
+			return []prometheus.Collector{failedJobGauge}, nil
+		},
+	},
+	{
+		Key:      "cluster_count",
+		Names:    []string{"gitlab_group_cluster_count"},
+		Features: []string{"clusters"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/clusters", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.ClusterCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getGroupClusterCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Clusters are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("clusters", labels)}, nil
+			}
+			fmt.Printf("Clusters of group %s: %d\n", group.ID, count.Total)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_cluster_count", "Number of certificate-based Kubernetes clusters of the GitLab group", labels, float64(count.Total)),
+			}, nil
+		},
+	},
+	{
+		Key:      "incident_count",
+		Names:    []string{"gitlab_group_incident_count", "gitlab_group_open_incident_count"},
+		Features: []string{"incident_management"},
+		Labels:   []string{"status"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id", Calls: "1 if the group is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1 per 100 open incidents, plus 1 per 100 closed incidents if priority is set", Paginated: true},
+			{Endpoint: "GET /groups/:id/issues", Calls: "1 if priority is not set"},
+		},
+		Enabled: func(group GroupConfig) bool { return group.IncidentCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getIncidentCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Incidents are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("incident_management", labels)}, nil
+			}
+
+			incidentCountGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_incident_count",
+				Help:        "Number of incidents of the GitLab group and its subgroups by escalation status",
+				ConstLabels: labels,
+			}, []string{"status"})
+			for _, status := range []string{incidentStatusTriggered, incidentStatusAcknowledged, incidentStatusResolved} {
+				if filter := group.IncidentCount.Status; filter != "" && filter != status {
+					continue
+				}
+				fmt.Printf("Incidents in group %s with status %s: %d\n", group.ID, status, count.ByStatus[status])
+				incidentCountGauge.WithLabelValues(status).Set(float64(count.ByStatus[status]))
+			}
+
+			return []prometheus.Collector{
+				incidentCountGauge,
+				newGauge("gitlab_group_open_incident_count", "Number of triggered and acknowledged incidents of the GitLab group and its subgroups", labels, float64(count.Open())),
+			}, nil
+		},
+	},
+	{
+		Key:      "push_rules_enabled",
+		Names:    []string{"gitlab_group_push_rules_enabled", "gitlab_group_push_rules_max_file_size_bytes"},
+		Features: []string{"push_rules"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/push_rule", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.PushRulesEnabled != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			rules, err := getGroupPushRules(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Push rules of group %s enabled: %t\n", group.ID, rules.Enabled)
+			return pushRulesCollectors("group", rules, labels), nil
+		},
+	},
+	{
+		Key:      "billable_member_count",
+		Names:    []string{"gitlab_group_billable_member_count"},
+		Features: []string{"billable_members"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/billable_members", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.BillableMemberCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getBillableMemberCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Billable members are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("billable_members", labels)}, nil
+			}
+			fmt.Printf("Billable members of group %s: %d\n", group.ID, count.Count)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_billable_member_count", "Number of members of the GitLab group that take up a paid seat", labels, float64(count.Count)),
+			}, nil
+		},
+	},
+	{
+		Key:      "seat_usage_ratio",
+		Names:    []string{"gitlab_group_seat_usage_ratio", "gitlab_group_seats_remaining"},
+		Features: []string{"billable_members"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/billable_members", Calls: "1"}},
+		Enabled:  func(group GroupConfig) bool { return group.SeatUsageRatio != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getBillableMemberCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Billable members are not available for group %s\n", group.ID)
+				return []prometheus.Collector{featureUnavailableGauge("billable_members", labels)}, nil
+			}
+
+			// Without a seat limit no seats are used up.
+			ratio, remaining := 0.0, math.Inf(1)
+			if totalSeats := group.SeatUsageRatio.TotalSeats; totalSeats > 0 {
+				ratio = float64(count.Count) / float64(totalSeats)
+				remaining = float64(totalSeats - count.Count)
+			}
+			fmt.Printf("Seats used by group %s: %d, remaining: %v\n", group.ID, count.Count, remaining)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_group_seat_usage_ratio", "Ratio of the seats of the GitLab group subscription taken up by billable members", labels, ratio),
+				newGauge("gitlab_group_seats_remaining", "Number of seats of the GitLab group subscription not taken up by billable members, +Inf if unlimited", labels, remaining),
+			}, nil
+		},
+	},
+	{
+		Key:    "comment_count",
+		Names:  []string{"gitlab_group_comment_count"},
+		Labels: []string{"resource_type"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/issues", Calls: "1 per 100 issues updated within within_days, unless resource_type is merge_request", Paginated: true},
+			{Endpoint: "GET /groups/:id/merge_requests", Calls: "1 per 100 merge requests updated within within_days, unless resource_type is issue", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.CommentCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			comments, err := getGroupCommentCount(ctx, git, group)
+			if err != nil {
+				return nil, err
+			}
+
+			commentGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_comment_count",
+				Help:        "Number of comments on the recently updated issues and merge requests of the GitLab group by resource type",
+				ConstLabels: labels,
+			}, []string{"resource_type"})
+			for _, resourceType := range []string{commentResourceIssue, commentResourceMergeRequest} {
+				if !group.CommentCount.counts(resourceType) {
+					continue
+				}
+				fmt.Printf("Comments on %ss in group %s: %d\n", resourceType, group.ID, comments[resourceType])
+				commentGauge.WithLabelValues(resourceType).Set(float64(comments[resourceType]))
+			}
+			return []prometheus.Collector{commentGauge}, nil
+		},
+	},
+	{
+		Key:    "project_age_distribution",
+		Names:  []string{"gitlab_group_project_age_bucket"},
+		Labels: []string{"age_bucket"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.ProjectAgeDistribution != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			distribution, err := getProjectAgeDistribution(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+
+			ageGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_project_age_bucket",
+				Help:        "Number of projects in the GitLab group by the age bucket of their creation date",
+				ConstLabels: labels,
+			}, []string{"age_bucket"})
+			for bucket, count := range distribution {
+				fmt.Printf("Projects in group %s aged %s: %d\n", group.ID, bucket, count)
+				ageGauge.WithLabelValues(bucket).Set(float64(count))
+			}
+			return []prometheus.Collector{ageGauge}, nil
+		},
+	},
+	{
+		Key:    "user_activity_stats",
+		Names:  []string{"gitlab_group_user_activity_events"},
+		Labels: []string{"username"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /groups/:id/projects", Calls: "1 per 100 projects, shared with other metrics", Paginated: true},
+			{Endpoint: "GET /groups/:id/members/all", Calls: "1 per 100 members", Paginated: true},
+			{Endpoint: "GET /users/:id/events", Calls: fmt.Sprintf("1 per member, up to max_members, %d by default, plus 1 per 100 events within within_days", defaultUserActivityMaxMembers), Paginated: true},
+		},
+		Enabled: func(group GroupConfig) bool { return group.UserActivityStats != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			activity, err := getUserActivityStats(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+
+			activityGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_group_user_activity_events",
+				Help:        "Number of contribution events of the most active members of the GitLab group within within_days days",
+				ConstLabels: labels,
+			}, []string{"username"})
+			for _, user := range activity {
+				fmt.Printf("Contribution events of %s in group %s within %d days: %d\n", user.Username, group.ID, group.UserActivityStats.withinDays(), user.Events)
+				activityGauge.WithLabelValues(user.Username).Set(float64(user.Events))
+			}
+			return []prometheus.Collector{activityGauge}, nil
+		},
+	},
+	{
+		Key:      "dormancy_detection",
+		Names:    []string{"gitlab_group_dormant", "gitlab_group_dormant_days"},
+		APICalls: []apiCall{{Endpoint: "GET /groups/:id/projects", Calls: "1, more if the most recently active projects are excluded"}},
+		Enabled:  func(group GroupConfig) bool { return group.DormancyDetection != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, group GroupConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			dormant, days, err := isGroupDormant(ctx, git, group, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Group %s dormant: %t, last activity %.0f days ago\n", group.ID, dormant, days)
+			if !dormant {
+				return []prometheus.Collector{
+					newGauge("gitlab_group_dormant", "Set to 1 if no project of the GitLab group was active within dormant_days days", labels, 0),
+				}, nil
+			}
+			return []prometheus.Collector{
+				newGauge("gitlab_group_dormant", "Set to 1 if no project of the GitLab group was active within dormant_days days", labels, 1),
+				newGauge("gitlab_group_dormant_days", "Days since the last activity in a project of the dormant GitLab group, +Inf if it has no projects", labels, days),
+			}, nil
+		},
+	},
+}
+
+var projectMetrics = []metricDefinition[ProjectConfig]{
+	{
+		Key:    "dependency_stats",
+		Names:  []string{"gitlab_project_dependency_count", "gitlab_project_vulnerable_dependency_count", "gitlab_project_dependency_critical_vulnerabilities", "gitlab_project_dependency_vulnerabilities"},
+		Labels: []string{"severity"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipelines/latest", Calls: "1"},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id/jobs", Calls: "1 per 100 jobs until the dependency scanning job", Paginated: true},
+			{Endpoint: "GET /projects/:id/jobs/:job_id/artifacts/" + dependencyScanningReport, Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.DependencyStats != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			stats, err := getDependencyStats(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Dependencies in project %s: %d total, %d vulnerable, %d critical vulnerabilities\n",
+				project.ID, stats.Total, stats.Vulnerable, stats.CriticalVulnerabilities)
+
+			severityGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_dependency_vulnerabilities",
+				Help:        "Number of dependency vulnerabilities in the GitLab project by severity",
+				ConstLabels: labels,
+			}, []string{"severity"})
+			for severity, count := range stats.Severities {
+				severityGauge.WithLabelValues(severity).Set(float64(count))
+			}
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_dependency_count", "Number of dependencies found by dependency scanning in the GitLab project", labels, float64(stats.Total)),
+				newGauge("gitlab_project_vulnerable_dependency_count", "Number of dependencies with at least one known vulnerability in the GitLab project", labels, float64(stats.Vulnerable)),
+				newGauge("gitlab_project_dependency_critical_vulnerabilities", "Number of critical dependency vulnerabilities in the GitLab project", labels, float64(stats.CriticalVulnerabilities)),
+				severityGauge,
+			}, nil
+		},
+	},
+	{
+		Key:      "clone_size",
+		Names:    []string{"gitlab_project_clone_size_bytes"},
+		APICalls: []apiCall{{Endpoint: "HEAD /projects/:id/repository/archive.tar.gz", Calls: "1-2"}, {Endpoint: "GET /projects/:id", Calls: "0-1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.CloneSize != nil && !skipCloneSize },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			cloneSize, err := getCloneSize(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Clone size of project %s: %d bytes\n", project.ID, cloneSize)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_clone_size_bytes", "Size of the tar.gz repository archive of the GitLab project in bytes", labels, float64(cloneSize)),
+			}, nil
+		},
+	},
+	{
+		Key:      "file_count",
+		Names:    []string{"gitlab_project_file_count", "gitlab_scrape_file_count_truncated"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/repository/tree", Calls: "1 per 100 entries per listed directory", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.FileCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			fileCount, truncated, err := getFileCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("File count in project %s: %d\n", project.ID, fileCount)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_project_file_count", "Number of files in the GitLab project repository", labels, float64(fileCount)),
+			}
+			if truncated {
+				fmt.Printf("File count in project %s was truncated at %d files\n", project.ID, fileCount)
+				collectors = append(collectors, newGauge("gitlab_scrape_file_count_truncated", "Set when the file count of the GitLab project stopped at the configured limit", labels, 1))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "contributor_domain_stats",
+		Names:    []string{"gitlab_project_internal_contributor_count", "gitlab_project_external_contributor_count"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/repository/contributors", Calls: "1 per 100 contributors", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.ContributorDomainStats != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			stats, err := getContributorDomainStats(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Contributors in project %s: %d internal, %d external\n", project.ID, stats.Internal, stats.External)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_internal_contributor_count", "Number of contributors of the GitLab project with an internal email domain", labels, float64(stats.Internal)),
+				newGauge("gitlab_project_external_contributor_count", "Number of contributors of the GitLab project with an external email domain", labels, float64(stats.External)),
+			}, nil
+		},
+	},
+	{
+		Key:    "pipeline_failure_breakdown",
+		Names:  []string{"gitlab_project_pipeline_failure_reason_count"},
+		Labels: []string{"reason"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipelines", Calls: "1"},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id/jobs", Calls: fmt.Sprintf("up to %d", maxFailedPipelinesToInspect), Paginated: true},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.PipelineFailureBreakdown != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			reasons, err := getPipelineFailureBreakdown(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+
+			failureReasonGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_pipeline_failure_reason_count",
+				Help:        "Number of failed jobs in recently failed pipelines of the GitLab project by failure reason",
+				ConstLabels: labels,
+			}, []string{"reason"})
+			for reason, count := range reasons {
+				fmt.Printf("Failed jobs in project %s with reason %s: %d\n", project.ID, reason, count)
+				failureReasonGauge.WithLabelValues(reason).Set(float64(count))
+			}
+
+			return []prometheus.Collector{failureReasonGauge}, nil
+		},
+	},
+	{
+		Key:      "pipeline_count",
+		Names:    []string{"gitlab_project_pipeline_count"},
+		Labels:   []string{"status", "ref"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/pipelines", Calls: fmt.Sprintf("1, or %d with split_by_status", len(pipelineStatuses))}},
+		Enabled:  func(project ProjectConfig) bool { return project.PipelineCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			counts, err := getPipelineCounts(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+
+			pipelineCountGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_pipeline_count",
+				Help:        "Number of pipelines of the GitLab project by status",
+				ConstLabels: labels,
+			}, []string{"status", "ref"})
+			for status, count := range counts {
+				fmt.Printf("Pipelines in project %s with status %s: %d\n", project.ID, status, count)
+				pipelineCountGauge.WithLabelValues(status, project.PipelineCount.Ref).Set(float64(count))
+			}
+
+			return []prometheus.Collector{pipelineCountGauge}, nil
+		},
+	},
+	{
+		Key:   "pipeline_avg_duration",
+		Names: []string{"gitlab_project_pipeline_avg_duration_seconds", "gitlab_project_recent_pipeline_duration_seconds"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipelines", Calls: "1"},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id", Calls: fmt.Sprintf("up to %d", maxPipelineDurationsToInspect)},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.PipelineAvgDuration != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			durations, err := getPipelineDurations(ctx, git, project.ID, project.PipelineAvgDuration)
+			if err != nil {
+				return nil, err
+			}
+			if durations.count() == 0 {
+				fmt.Printf("No finished %s pipelines in project %s\n", project.PipelineAvgDuration.status(), project.ID)
+				return nil, nil
+			}
+			fmt.Printf("Average pipeline duration in project %s: %.0fs over %d pipelines\n", project.ID, durations.average(), durations.count())
+
+			histogram := newDurationSnapshotHistogram("gitlab_project_recent_pipeline_duration_seconds", "Duration of the pipelines finished recently in the GitLab project as of the last scrape, in seconds", labels)
+			for _, seconds := range durations.Seconds {
+				histogram.Observe(seconds)
+			}
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_pipeline_avg_duration_seconds", "Average duration of recently finished pipelines in the GitLab project in seconds", labels, durations.average()),
+				histogram,
+			}, nil
+		},
+	},
+	{
+		Key:      "code_coverage",
+		Names:    []string{"gitlab_project_code_coverage_percent", "gitlab_project_coverage_configured"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/pipelines/latest", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.CodeCoverage != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			coverage, ok, err := getCodeCoverage(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+
+			configured := 1.0
+			if ok {
+				fmt.Printf("Code coverage of project %s: %.2f%%\n", project.ID, coverage)
+			} else {
+				fmt.Printf("Latest pipeline of project %s reports no code coverage\n", project.ID)
+				coverage, configured = -1, 0
+			}
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_code_coverage_percent", "Code coverage of the latest pipeline of the GitLab project in percent, -1 if it reports none", labels, coverage),
+				newGauge("gitlab_project_coverage_configured", "Set to 1 if the latest pipeline of the GitLab project reports code coverage", labels, configured),
+			}, nil
+		},
+	},
+	{
+		Key:   "test_report_stats",
+		Names: []string{"gitlab_project_tests_total", "gitlab_project_tests_passed", "gitlab_project_tests_failed", "gitlab_project_tests_error", "gitlab_project_test_duration_seconds"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipelines/latest", Calls: "1 without pipeline_id"},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id/test_report", Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.TestReportStats != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			report, err := getTestReportStats(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Tests in project %s: %d total, %d passed, %d failed, %d errors\n", project.ID, report.TotalCount, report.SuccessCount, report.FailedCount, report.ErrorCount)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_tests_total", "Number of tests in the test report of the GitLab project", labels, float64(report.TotalCount)),
+				newGauge("gitlab_project_tests_passed", "Number of passed tests in the test report of the GitLab project", labels, float64(report.SuccessCount)),
+				newGauge("gitlab_project_tests_failed", "Number of failed tests in the test report of the GitLab project", labels, float64(report.FailedCount)),
+				newGauge("gitlab_project_tests_error", "Number of tests with errors in the test report of the GitLab project", labels, float64(report.ErrorCount)),
+				newGauge("gitlab_project_test_duration_seconds", "Total duration of the tests in the test report of the GitLab project in seconds", labels, report.TotalTime),
+			}, nil
+		},
+	},
+	{
+		Key:      "security_policy_count",
+		Names:    []string{"gitlab_project_scan_execution_policy_count", "gitlab_project_mr_approval_policy_count"},
+		Features: []string{"security_policies"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1 if the project is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.SecurityPolicyCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getSecurityPolicyCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Security policies are not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("security_policies", labels)}, nil
+			}
+			fmt.Printf("Security policies in project %s: %d scan execution, %d merge request approval\n",
+				project.ID, count.ScanExecution, count.MergeRequestApproval)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_scan_execution_policy_count", "Number of scan execution policies applied to the GitLab project", labels, float64(count.ScanExecution)),
+				newGauge("gitlab_project_mr_approval_policy_count", "Number of merge request approval policies applied to the GitLab project", labels, float64(count.MergeRequestApproval)),
+			}, nil
+		},
+	},
+	{
+		Key:   "infrastructure_target",
+		Names: []string{"gitlab_project_terraform_state_count"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1 if the project is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.InfrastructureTarget != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			// Projects without GitLab managed Terraform state are reported
+			// as having no states.
+			count, err := getTerraformStateCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Terraform states in project %s: %d\n", project.ID, count.Count)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_terraform_state_count", "Number of GitLab managed Terraform states in the GitLab project", labels, float64(count.Count)),
+			}, nil
+		},
+	},
+	{
+		Key:      "service_integration_count",
+		Names:    []string{"gitlab_project_integration_count"},
+		Labels:   []string{"integration_type"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/services", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.ServiceIntegrationCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			counts, err := getServiceIntegrationCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+
+			if !project.ServiceIntegrationCount.SplitByType {
+				var total int
+				for _, count := range counts {
+					total += count
+				}
+				fmt.Printf("Active integrations in project %s: %d\n", project.ID, total)
+				return []prometheus.Collector{
+					newGauge("gitlab_project_integration_count", "Number of active integrations of the GitLab project", labels, float64(total)),
+				}, nil
+			}
+
+			integrationGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_integration_count",
+				Help:        "Number of active integrations of the GitLab project by type",
+				ConstLabels: labels,
+			}, []string{"integration_type"})
+			for integrationType, count := range counts {
+				fmt.Printf("Active %s integrations in project %s: %d\n", integrationType, project.ID, count)
+				integrationGauge.WithLabelValues(integrationType).Set(float64(count))
+			}
+			return []prometheus.Collector{integrationGauge}, nil
+		},
+	},
+	{
+		Key:      "project_fork_network",
+		Names:    []string{"gitlab_project_fork_network_depth", "gitlab_project_is_fork"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id", Calls: fmt.Sprintf("1 plus 1 per fork parent, up to max_depth, %d by default", defaultForkNetworkMaxDepth)}},
+		Enabled:  func(project ProjectConfig) bool { return project.ProjectForkNetwork != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			depth, err := getProjectForkNetwork(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Fork network depth of project %s: %d\n", project.ID, depth)
+
+			isFork := 0.0
+			if depth > 0 {
+				isFork = 1
+			}
+			return []prometheus.Collector{
+				newGauge("gitlab_project_fork_network_depth", "Number of projects in the chain the GitLab project was forked from", labels, float64(depth)),
+				newGauge("gitlab_project_is_fork", "Set to 1 if the GitLab project is a fork", labels, isFork),
+			}, nil
+		},
+	},
+	{
+		Key:      "project_protected_tag_count",
+		Names:    []string{"gitlab_project_protected_tag_count", "gitlab_project_protected_tag_wildcard_count"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/protected_tags", Calls: "1 per 100 protected tags", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.ProjectProtectedTagCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getProtectedTagCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Protected tags in project %s: %d, %d wildcard\n", project.ID, count.Total, count.Wildcard)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_protected_tag_count", "Number of protected tags and tag patterns of the GitLab project", labels, float64(count.Total)),
+				newGauge("gitlab_project_protected_tag_wildcard_count", "Number of protected tag patterns of the GitLab project with a wildcard", labels, float64(count.Wildcard)),
+			}, nil
+		},
+	},
+	{
+		Key:      "badge_count",
+		Names:    []string{"gitlab_project_badge_count", "gitlab_project_group_inherited_badge_count"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/badges", Calls: "1 per 100 badges", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.BadgeCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getBadgeCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Badges of project %s: %d, %d inherited from groups\n", project.ID, count.Total, count.GroupInherited)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_badge_count", "Number of badges of the GitLab project, including the badges of its groups", labels, float64(count.Total)),
+				newGauge("gitlab_project_group_inherited_badge_count", "Number of badges the GitLab project inherits from its groups", labels, float64(count.GroupInherited)),
+			}, nil
+		},
+	},
+	{
+		Key: "ci_job_artifact_count",
+		NamesFor: func(project ProjectConfig) []string {
+			if project.CIJobArtifactCount.IncludeExpired {
+				return []string{"gitlab_project_artifact_file_count", "gitlab_project_expired_artifact_count"}
+			}
+			return []string{"gitlab_project_artifact_file_count"}
+		},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipelines", Calls: "1 per 100 pipelines up to max_pipelines", Paginated: true},
+			{Endpoint: "GET /projects/:id/pipelines/:pipeline_id/jobs", Calls: "1 per inspected pipeline and 100 jobs", Paginated: true},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.CIJobArtifactCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getArtifactCount(ctx, git, project, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Job artifacts in the recent pipelines of project %s: %d\n", project.ID, count.Files)
+
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_project_artifact_file_count", "Number of jobs with unexpired artifacts in the recent pipelines of the GitLab project", labels, float64(count.Files)),
+			}
+			if project.CIJobArtifactCount.IncludeExpired {
+				fmt.Printf("Expired job artifacts in the recent pipelines of project %s: %d\n", project.ID, count.Expired)
+				collectors = append(collectors, newGauge("gitlab_project_expired_artifact_count", "Number of jobs with expired artifacts in the recent pipelines of the GitLab project", labels, float64(count.Expired)))
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:      "cluster_count",
+		Names:    []string{"gitlab_project_cluster_count", "gitlab_project_active_cluster_count"},
+		Features: []string{"clusters"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/clusters", Calls: "1"},
+			{Endpoint: "GET /projects/:id/cluster_agents", Calls: "1 per 100 agents", Paginated: true},
+			{Endpoint: "GET /projects/:id/cluster_agents/:agent_id/tokens", Calls: "1 per agent"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.ClusterCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getProjectClusterCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Clusters are not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("clusters", labels)}, nil
+			}
+			fmt.Printf("Clusters of project %s: %d, %d active\n", project.ID, count.Total, count.Active)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_cluster_count", "Number of certificate-based Kubernetes clusters and Kubernetes agents of the GitLab project", labels, float64(count.Total)),
+				newGauge("gitlab_project_active_cluster_count", "Number of enabled certificate-based Kubernetes clusters and Kubernetes agents with an active token of the GitLab project", labels, float64(count.Active)),
+			}, nil
+		},
+	},
+	{
+		Key:      "terraform_state_count",
+		Names:    []string{"gitlab_project_terraform_state_count"},
+		Features: []string{"terraform_state"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1 if the project is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.TerraformStateCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getTerraformStateCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("GitLab managed Terraform state is not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("terraform_state", labels)}, nil
+			}
+			fmt.Printf("Terraform states in project %s: %d\n", project.ID, count.Count)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_terraform_state_count", "Number of GitLab managed Terraform states in the GitLab project", labels, float64(count.Count)),
+			}, nil
+		},
+	},
+	{
+		Key:      "alert_count",
+		Names:    []string{"gitlab_project_alert_count"},
+		Features: []string{"alert_management"},
+		Labels:   []string{"status", "severity"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1 if the project is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1 per 100 alerts", Paginated: true},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.AlertCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getAlertCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Alert management is not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("alert_management", labels)}, nil
+			}
+
+			alertCountGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_alert_count",
+				Help:        "Number of alerts of the GitLab project by status and severity",
+				ConstLabels: labels,
+			}, []string{"status", "severity"})
+			for key, alerts := range count.Counts {
+				fmt.Printf("Alerts in project %s with status %s and severity %s: %d\n", project.ID, key.Status, key.Severity, alerts)
+				alertCountGauge.WithLabelValues(key.Status, key.Severity).Set(float64(alerts))
+			}
+			return []prometheus.Collector{alertCountGauge}, nil
+		},
+	},
+	{
+		Key:      "oncall_schedule_count",
+		Names:    []string{"gitlab_project_oncall_schedule_count", "gitlab_project_oncall_participants_count"},
+		Features: []string{"oncall_schedules"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1 if the project is configured by numeric ID"},
+			{Endpoint: "POST /api/graphql", Calls: "1"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.OncallScheduleCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getOncallScheduleCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("On-call schedules are not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("oncall_schedules", labels)}, nil
+			}
+			fmt.Printf("On-call schedules of project %s: %d with %d participants\n", project.ID, count.Schedules, count.Participants)
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_oncall_schedule_count", "Number of on-call schedules of the GitLab project", labels, float64(count.Schedules)),
+				newGauge("gitlab_project_oncall_participants_count", "Number of participants of the rotations of all on-call schedules of the GitLab project", labels, float64(count.Participants)),
+			}, nil
+		},
+	},
+	{
+		Key:      "feature_flag_count",
+		Names:    []string{"gitlab_project_feature_flag_count", "gitlab_project_active_feature_flag_count", "gitlab_project_feature_flag_strategy_count"},
+		Features: []string{"feature_flags"},
+		Labels:   []string{"strategy"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/feature_flags", Calls: "1 per 100 feature flags", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.FeatureFlagCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getFeatureFlagCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Feature flags are not available for project %s\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("feature_flags", labels)}, nil
+			}
+			fmt.Printf("Feature flags of project %s: %d, %d active\n", project.ID, count.Total, count.Active)
+
+			strategyGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Name:        "gitlab_project_feature_flag_strategy_count",
+				Help:        "Number of strategies of the feature flags of the GitLab project by strategy",
+				ConstLabels: labels,
+			}, []string{"strategy"})
+			for strategy, strategies := range count.Strategies {
+				strategyGauge.WithLabelValues(strategy).Set(float64(strategies))
+			}
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_feature_flag_count", "Number of feature flags of the GitLab project", labels, float64(count.Total)),
+				newGauge("gitlab_project_active_feature_flag_count", "Number of active feature flags of the GitLab project", labels, float64(count.Active)),
+				strategyGauge,
+			}, nil
+		},
+	},
+	{
+		Key:   "error_tracking_count",
+		Names: []string{"gitlab_project_error_tracking_issue_count", "gitlab_project_error_tracking_enabled"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/error_tracking/settings", Calls: "1"},
+			{Endpoint: "GET /projects/:id/error_tracking/errors", Calls: "1 if error tracking is enabled"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.ErrorTrackingCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getErrorTrackingCount(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			enabled := 0.0
+			if count.Enabled {
+				enabled = 1
+				fmt.Printf("Error tracking issues in project %s: %d\n", project.ID, count.Issues)
+			} else {
+				fmt.Printf("Error tracking is not enabled for project %s\n", project.ID)
+			}
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_error_tracking_issue_count", "Number of error tracking issues of the GitLab project", labels, float64(count.Issues)),
+				newGauge("gitlab_project_error_tracking_enabled", "Set to 1 if error tracking is enabled for the GitLab project", labels, enabled),
+			}, nil
+		},
+	},
+	{
+		Key:      "status_page_count",
+		Names:    []string{"gitlab_project_status_page_enabled"},
+		Features: []string{"status_page"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/status_page/setting", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.StatusPageCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			statusPage, err := getStatusPageEnabled(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !statusPage.Available {
+				fmt.Printf("The status page setting of project %s is not available\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("status_page", labels)}, nil
+			}
+
+			enabled := 0.0
+			if statusPage.Enabled {
+				enabled = 1
+			}
+			fmt.Printf("Status page of project %s enabled: %t\n", project.ID, statusPage.Enabled)
+			return []prometheus.Collector{
+				newGauge("gitlab_project_status_page_enabled", "Set to 1 if the GitLab project publishes a status page", labels, enabled),
+			}, nil
+		},
+	},
+	{
+		Key:      "push_rules_enabled",
+		Names:    []string{"gitlab_project_push_rules_enabled", "gitlab_project_push_rules_max_file_size_bytes"},
+		Features: []string{"push_rules"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/push_rule", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.PushRulesEnabled != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			rules, err := getProjectPushRules(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Push rules of project %s enabled: %t\n", project.ID, rules.Enabled)
+			return pushRulesCollectors("project", rules, labels), nil
+		},
+	},
+	{
+		Key:   "protection_score",
+		Names: []string{"gitlab_project_protection_score"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1"},
+			{Endpoint: "GET /projects/:id/repository/branches/:branch", Calls: "1"},
+			{Endpoint: "GET /projects/:id/push_rule", Calls: "1"},
+			{Endpoint: "GET /projects/:id/approval_rules", Calls: "1"},
+			{Endpoint: "HEAD /projects/:id/repository/files/.gitlab-ci.yml", Calls: "1 without a custom ci_config_path"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.ProtectionScore != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			score, err := getProjectProtectionScore(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Protection score of project %s: %v (%s)\n", project.ID, score.Score, formatChecks(protectionChecks, score.Checks))
+			return []prometheus.Collector{
+				newGauge("gitlab_project_protection_score", "Sum of the weights of the protections of the GitLab project: a protected default branch, push rules, required approvals and a CI/CD configuration", labels, score.Score),
+			}, nil
+		},
+	},
+	{
+		Key:      "pipeline_trigger_count",
+		Names:    []string{"gitlab_project_pipeline_trigger_count", "gitlab_project_stale_pipeline_trigger_count"},
+		Features: []string{"pipeline_triggers"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/triggers", Calls: "1 per 100 triggers", Paginated: true}},
+		Enabled:  func(project ProjectConfig) bool { return project.PipelineTriggerCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getPipelineTriggerCount(ctx, git, project, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			if !count.Available {
+				fmt.Printf("Pipeline triggers of project %s are not available\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("pipeline_triggers", labels)}, nil
+			}
+			fmt.Printf("Pipeline triggers of project %s: %d, %d not used in %d days\n", project.ID, count.Total, count.Stale, project.PipelineTriggerCount.unusedDays())
+
+			return []prometheus.Collector{
+				newGauge("gitlab_project_pipeline_trigger_count", "Number of pipeline triggers of the GitLab project", labels, float64(count.Total)),
+				newGauge("gitlab_project_stale_pipeline_trigger_count", "Number of pipeline triggers of the GitLab project not used within unused_days days", labels, float64(count.Stale)),
+			}, nil
+		},
+	},
+	{
+		Key: "scheduled_pipeline_count",
+		Names: []string{
+			"gitlab_project_pipeline_schedule_count", "gitlab_project_active_pipeline_schedule_count",
+			"gitlab_project_inactive_pipeline_schedule_count", "gitlab_project_next_scheduled_pipeline_seconds",
+		},
+		NamesFor: func(project ProjectConfig) []string {
+			if project.ScheduledPipelineCount.SplitByStatus {
+				return []string{"gitlab_project_active_pipeline_schedule_count", "gitlab_project_inactive_pipeline_schedule_count", "gitlab_project_next_scheduled_pipeline_seconds"}
+			}
+			return []string{"gitlab_project_pipeline_schedule_count", "gitlab_project_next_scheduled_pipeline_seconds"}
+		},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id/pipeline_schedules?scope=active", Calls: "1 per 100 active schedules", Paginated: true},
+			{Endpoint: "GET /projects/:id/pipeline_schedules?scope=inactive", Calls: "1 unless active is true"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.ScheduledPipelineCount != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			count, err := getScheduledPipelineCount(ctx, git, project, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Pipeline schedules of project %s: %d active, %d inactive, next run in %.0fs\n", project.ID, count.Active, count.Inactive, count.NextRunSeconds)
+
+			config := project.ScheduledPipelineCount
+			var collectors []prometheus.Collector
+			if config.SplitByStatus {
+				collectors = append(collectors,
+					newGauge("gitlab_project_active_pipeline_schedule_count", "Number of active pipeline schedules of the GitLab project", labels, float64(count.Active)),
+					newGauge("gitlab_project_inactive_pipeline_schedule_count", "Number of inactive pipeline schedules of the GitLab project", labels, float64(count.Inactive)),
+				)
+			} else {
+				schedules := count.Active + count.Inactive
+				if config.Active != nil && *config.Active {
+					schedules = count.Active
+				} else if config.Active != nil {
+					schedules = count.Inactive
+				}
+				collectors = append(collectors, newGauge("gitlab_project_pipeline_schedule_count", "Number of pipeline schedules of the GitLab project, only active or inactive ones if configured", labels, float64(schedules)))
+			}
+			return append(collectors,
+				newGauge("gitlab_project_next_scheduled_pipeline_seconds", "Seconds until the next run of an active pipeline schedule of the GitLab project, +Inf if there is none", labels, count.NextRunSeconds),
+			), nil
+		},
+	},
+	{
+		Key:      "sentry_integration_status",
+		Names:    []string{"gitlab_project_sentry_integration_enabled"},
+		Features: []string{"error_tracking"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/error_tracking/settings", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.SentryIntegrationStatus != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			sentry, err := getSentryIntegrationStatus(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !sentry.Available {
+				fmt.Printf("The error tracking settings of project %s are not available\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("error_tracking", labels)}, nil
+			}
+
+			enabled := 0.0
+			if sentry.Enabled {
+				enabled = 1
+			}
+			fmt.Printf("Sentry integration of project %s enabled: %t\n", project.ID, sentry.Enabled)
+			return []prometheus.Collector{
+				newGauge("gitlab_project_sentry_integration_enabled", "Set to 1 if the GitLab project reports errors to Sentry through its error tracking settings", labels, enabled),
+			}, nil
+		},
+	},
+	{
+		Key:      "jira_integration_status",
+		Names:    []string{"gitlab_project_jira_integration_enabled", "gitlab_project_jira_project_key"},
+		Features: []string{"jira_integration"},
+		Labels:   []string{"jira_project_key"},
+		APICalls: []apiCall{{Endpoint: "GET /projects/:id/integrations/jira", Calls: "1"}},
+		Enabled:  func(project ProjectConfig) bool { return project.JiraIntegrationStatus != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			jira, err := getJiraIntegrationStatus(ctx, git, project)
+			if err != nil {
+				return nil, err
+			}
+			if !jira.Available {
+				fmt.Printf("The Jira integration of project %s is not available\n", project.ID)
+				return []prometheus.Collector{featureUnavailableGauge("jira_integration", labels)}, nil
+			}
+
+			enabled := 0.0
+			if jira.Enabled {
+				enabled = 1
+			}
+			fmt.Printf("Jira integration of project %s enabled: %t\n", project.ID, jira.Enabled)
+			collectors := []prometheus.Collector{
+				newGauge("gitlab_project_jira_integration_enabled", "Set to 1 if the Jira integration of the GitLab project is active", labels, enabled),
+			}
+			if len(jira.ProjectKeys) > 0 {
+				keyGauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+					Name:        "gitlab_project_jira_project_key",
+					Help:        "Set to 1 for every Jira project key the Jira integration of the GitLab project is restricted to",
+					ConstLabels: labels,
+				}, []string{"jira_project_key"})
+				for _, key := range jira.ProjectKeys {
+					keyGauge.WithLabelValues(key).Set(1)
+				}
+				collectors = append(collectors, keyGauge)
+			}
+			return collectors, nil
+		},
+	},
+	{
+		Key:   "repository_health",
+		Names: []string{"gitlab_project_repository_health_score"},
+		APICalls: []apiCall{
+			{Endpoint: "GET /projects/:id", Calls: "1"},
+			{Endpoint: "GET /projects/:id/repository/branches/:branch", Calls: "1"},
+			{Endpoint: "HEAD /projects/:id/repository/files/.gitlab-ci.yml", Calls: "1 without a custom ci_config_path"},
+		},
+		Enabled: func(project ProjectConfig) bool { return project.RepositoryHealth != nil },
+		Collect: func(ctx context.Context, git *gitlab.Client, project ProjectConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+			health, err := getRepositoryHealth(ctx, git, project, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("Repository health score of project %s: %v (%s)\n", project.ID, health.Score, formatChecks(healthChecks, health.Checks))
+			return []prometheus.Collector{
+				newGauge("gitlab_project_repository_health_score", "Sum of the weights of the health checks the GitLab project passes: a README, a CI/CD configuration, a protected default branch, a recent commit and few open issues", labels, health.Score),
+			}, nil
+		},
+	},
+}
@@ -0,0 +1,152 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func TestCollectMetricsSkipsUnconfiguredMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/groups/1/members" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Total", "7")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+
+	git, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group := GroupConfig{ID: "1", MemberCount: &MemberCountConfig{}}
+	collectors, err := collectMetrics(context.Background(), groupMetrics, git, group, prometheus.Labels{"group_id": group.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := collectorSums(collectors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values["gitlab_group_members_count"] != 7 {
+		t.Errorf("collected %v, want only gitlab_group_members_count 7", values)
+	}
+}
+
+func TestCollectMetricsReportsUnavailableFeatureOnce(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+
+	git, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group := GroupConfig{ID: "1", BillableMemberCount: &BillableMemberConfig{}, SeatUsageRatio: &SeatConfig{}}
+	collectors, err := collectMetrics(context.Background(), groupMetrics, git, group, prometheus.Labels{"group_id": group.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("failed to register %d collectors: %v", len(collectors), err)
+		}
+	}
+	if len(collectors) != 1 {
+		t.Errorf("collected %d collectors, want a single feature unavailable gauge", len(collectors))
+	}
+}
+
+func TestValidateUniqueSeriesChecksUnavailableFeatures(t *testing.T) {
+	config := &Config{Groups: []GroupConfig{
+		{ID: "1", BillableMemberCount: &BillableMemberConfig{}},
+		{ID: "1", SeatUsageRatio: &SeatConfig{}},
+	}}
+	if err := validateUniqueSeries(config); err == nil {
+		t.Error("two entries of the same group pushing the billable_members feature are accepted")
+	}
+
+	config.Groups = []GroupConfig{{ID: "1", BillableMemberCount: &BillableMemberConfig{}, SeatUsageRatio: &SeatConfig{}}}
+	if err := validateUniqueSeries(config); err != nil {
+		t.Errorf("metrics of one entry sharing a feature are rejected: %v", err)
+	}
+}
+
+func approvalsServer(t *testing.T, unavailableIIDs ...string) *gitlab.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v4/groups/1/merge_requests":
+			_, _ = w.Write([]byte(`[{"iid": 1, "project_id": 2, "created_at": "2025-01-01T00:00:00Z"}, {"iid": 2, "project_id": 2, "created_at": "2025-01-01T00:00:00Z"}]`))
+		case "/api/v4/projects/2/merge_requests/1/approvals", "/api/v4/projects/2/merge_requests/2/approvals":
+			for _, iid := range unavailableIIDs {
+				if r.URL.Path == "/api/v4/projects/2/merge_requests/"+iid+"/approvals" {
+					http.NotFound(w, r)
+					return
+				}
+			}
+			_, _ = w.Write([]byte(`{"approvals_left": 1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	git, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return git
+}
+
+func TestApprovalWaitTimesSkipUnavailableMergeRequest(t *testing.T) {
+	now := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+	group := GroupConfig{ID: "1", MRApprovalWaitTime: &ApprovalWaitConfig{WindowDays: 30}}
+
+	waitTimes, err := getApprovalWaitTimes(context.Background(), approvalsServer(t, "1"), group, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !waitTimes.Available || len(waitTimes.Waiting) != 1 {
+		t.Errorf("got %d waiting merge requests with availability %t, want 1 available", len(waitTimes.Waiting), waitTimes.Available)
+	}
+
+	waitTimes, err = getApprovalWaitTimes(context.Background(), approvalsServer(t, "1", "2"), group, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waitTimes.Available {
+		t.Error("approvals are available although they are unavailable for every merge request")
+	}
+}
+
+func TestApprovalMetricsShareUnavailableFeature(t *testing.T) {
+	group := GroupConfig{ID: "1", ApprovalRuleBreakdown: &ApprovalBreakdownConfig{}, MRApprovalWaitTime: &ApprovalWaitConfig{}}
+	collectors, err := collectMetrics(context.Background(), groupMetrics, approvalsServer(t, "1", "2"), group, prometheus.Labels{"group_id": group.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			t.Fatalf("failed to register %d collectors: %v", len(collectors), err)
+		}
+	}
+	if len(collectors) != 1 {
+		t.Errorf("collected %d collectors, want a single feature unavailable gauge", len(collectors))
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	namespaceStatStorage      = "storage"
+	namespaceStatCIMinutes    = "ci_minutes"
+	namespaceStatRepositories = "repositories"
+)
+
+var namespaceStatNames = []string{namespaceStatStorage, namespaceStatCIMinutes, namespaceStatRepositories}
+
+// collects reports whether stat is scraped for the namespace, which is every
+// stat if none are configured.
+func (c NamespaceConfig) collects(stat string) bool {
+	return len(c.Stats) == 0 || slices.Contains(c.Stats, stat)
+}
+
+func validateNamespaces(namespaces []NamespaceConfig) error {
+	for i, namespace := range namespaces {
+		if namespace.ID == "" {
+			return fmt.Errorf("namespaces[%d] has no id", i)
+		}
+		for _, stat := range namespace.Stats {
+			if !slices.Contains(namespaceStatNames, stat) {
+				return fmt.Errorf("unknown stat %q of namespace %s, expected one of %s", stat, namespace.ID, strings.Join(namespaceStatNames, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+type NamespaceStats struct {
+	Kind             string
+	StorageUsedBytes float64
+	// StorageLimitBytes is 0 for namespaces without a storage limit.
+	StorageLimitBytes float64
+	CIMinutesUsed     float64
+	// CIMinutesAvailable is false on instances without CI/CD minutes
+	// tracking, which is a GitLab EE feature.
+	CIMinutesAvailable bool
+	Repositories       int
+	// Available is false when the storage statistics of the namespace
+	// cannot be read, e.g. on instances without them.
+	Available bool
+}
+
+const namespaceStatsQuery = `query($fullPath: ID!) {
+  namespace(fullPath: $fullPath) {
+    storageSizeLimit
+    rootStorageStatistics {
+      storageSize
+    }
+    projects(includeSubgroups: true) {
+      count
+    }
+  }
+}`
+
+// ciMinutesUsageQuery is sent on its own, since ciMinutesUsage is a GitLab
+// EE field that fails the whole query on other instances.
+const ciMinutesUsageQuery = `query($namespaceId: NamespaceID!, $month: Date!) {
+  ciMinutesUsage(namespaceId: $namespaceId, date: $month) {
+    nodes {
+      minutes
+    }
+  }
+}`
+
+type namespaceStatsResponse struct {
+	Namespace *struct {
+		StorageSizeLimit      *float64 `json:"storageSizeLimit"`
+		RootStorageStatistics *struct {
+			StorageSize float64 `json:"storageSize"`
+		} `json:"rootStorageStatistics"`
+		Projects struct {
+			Count int `json:"count"`
+		} `json:"projects"`
+	} `json:"namespace"`
+}
+
+type ciMinutesUsageResponse struct {
+	CIMinutesUsage *struct {
+		Nodes []struct {
+			Minutes float64 `json:"minutes"`
+		} `json:"nodes"`
+	} `json:"ciMinutesUsage"`
+}
+
+// getNamespaceStats reads the storage, CI/CD minutes and repositories of a
+// group or user namespace. The ID is looked up through the REST API, which
+// accepts the numeric ID or the path of both kinds, while the statistics are
+// only exposed through GraphQL. Storage statistics exist for top-level
+// namespaces only. The CI/CD minutes are those of the current month and are
+// only queried if ci_minutes is collected.
+func getNamespaceStats(ctx context.Context, git *gitlab.Client, namespace NamespaceConfig, now time.Time) (NamespaceStats, error) {
+	ns, resp, err := git.Namespaces.GetNamespace(namespace.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return NamespaceStats{}, nil
+	}
+	if err != nil {
+		return NamespaceStats{}, fmt.Errorf("failed to get namespace %s: %w", namespace.ID, err)
+	}
+
+	var stats namespaceStatsResponse
+	ok, err := doGraphQL(ctx, git, namespaceStatsQuery, map[string]any{"fullPath": ns.FullPath}, &stats)
+	if err != nil {
+		return NamespaceStats{}, fmt.Errorf("failed to get statistics of namespace %s: %w", namespace.ID, err)
+	}
+
+	result := stats.Namespace
+	if !ok || result == nil || result.RootStorageStatistics == nil {
+		return NamespaceStats{Kind: ns.Kind}, nil
+	}
+
+	namespaceStats := NamespaceStats{
+		Kind:             ns.Kind,
+		StorageUsedBytes: result.RootStorageStatistics.StorageSize,
+		Repositories:     result.Projects.Count,
+		Available:        true,
+	}
+	if result.StorageSizeLimit != nil {
+		namespaceStats.StorageLimitBytes = *result.StorageSizeLimit
+	}
+	if !namespace.collects(namespaceStatCIMinutes) {
+		return namespaceStats, nil
+	}
+
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var usage ciMinutesUsageResponse
+	ok, err = doGraphQL(ctx, git, ciMinutesUsageQuery, map[string]any{
+		"namespaceId": fmt.Sprintf("gid://gitlab/Namespace/%d", ns.ID),
+		"month":       month.Format(time.DateOnly),
+	}, &usage)
+	if err != nil {
+		return NamespaceStats{}, fmt.Errorf("failed to get CI/CD minutes of namespace %s: %w", namespace.ID, err)
+	}
+	if ok && usage.CIMinutesUsage != nil {
+		namespaceStats.CIMinutesAvailable = true
+		for _, node := range usage.CIMinutesUsage.Nodes {
+			namespaceStats.CIMinutesUsed += node.Minutes
+		}
+	}
+	return namespaceStats, nil
+}
+
+func collectNamespaceStats(ctx context.Context, git *gitlab.Client, namespace NamespaceConfig, labels prometheus.Labels) ([]prometheus.Collector, error) {
+	stats, err := getNamespaceStats(ctx, git, namespace, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !stats.Available {
+		fmt.Printf("Statistics of namespace %s are not available\n", namespace.ID)
+		return []prometheus.Collector{featureUnavailableGauge("namespace_statistics", labels)}, nil
+	}
+
+	labels = mergeLabels(labels, prometheus.Labels{"namespace_kind": stats.Kind})
+	var collectors []prometheus.Collector
+	if namespace.collects(namespaceStatStorage) {
+		fmt.Printf("Storage of namespace %s: %v of %v bytes\n", namespace.ID, stats.StorageUsedBytes, stats.StorageLimitBytes)
+		collectors = append(collectors,
+			newGauge("gitlab_namespace_storage_used_bytes", "Storage used by the GitLab namespace in bytes", labels, stats.StorageUsedBytes),
+			newGauge("gitlab_namespace_storage_limit_bytes", "Storage limit of the GitLab namespace in bytes, 0 if unlimited", labels, stats.StorageLimitBytes),
+		)
+	}
+	if namespace.collects(namespaceStatCIMinutes) {
+		if stats.CIMinutesAvailable {
+			fmt.Printf("CI/CD minutes used by namespace %s this month: %v\n", namespace.ID, stats.CIMinutesUsed)
+			collectors = append(collectors, newGauge("gitlab_namespace_ci_minutes_used", "CI/CD minutes used by the GitLab namespace in the current month", labels, stats.CIMinutesUsed))
+		} else {
+			fmt.Printf("CI/CD minutes of namespace %s are not available\n", namespace.ID)
+			collectors = append(collectors, featureUnavailableGauge("namespace_ci_minutes", labels))
+		}
+	}
+	if namespace.collects(namespaceStatRepositories) {
+		fmt.Printf("Repositories in namespace %s: %d\n", namespace.ID, stats.Repositories)
+		collectors = append(collectors, newGauge("gitlab_namespace_repositories_count", "Number of project repositories in the GitLab namespace", labels, float64(stats.Repositories)))
+	}
+	return collectors, nil
+}
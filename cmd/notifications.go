@@ -0,0 +1,36 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type GroupSubscriptionStatus struct {
+	// Watching is true if the user of the token watches the group.
+	Watching bool
+	// Available is false if the notification settings of the group cannot
+	// be read with the token.
+	Available bool
+}
+
+// getGroupSubscriptionStatus reads the notification level of the user of
+// the token for the group. GitLab only exposes the notification settings of
+// the current user, so the watchers a scraper can see are at most itself.
+//
+// TODO: count every watcher once GitLab offers an API that lists the
+// notification settings of all members of a group.
+func getGroupSubscriptionStatus(ctx context.Context, git *gitlab.Client, group GroupConfig) (GroupSubscriptionStatus, error) {
+	settings, resp, err := git.NotificationSettings.GetSettingsForGroup(group.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return GroupSubscriptionStatus{}, nil
+	}
+	if err != nil {
+		return GroupSubscriptionStatus{}, fmt.Errorf("failed to get notification settings for group %s: %w", group.ID, err)
+	}
+	return GroupSubscriptionStatus{Watching: settings.Level == gitlab.WatchNotificationLevel, Available: true}, nil
+}
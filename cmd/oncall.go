@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type OncallScheduleCount struct {
+	Schedules int
+	// Participants sums the participants of the rotations of every
+	// schedule, so a user in two rotations is counted twice.
+	Participants int
+	// Available is false on instances without on-call schedules, which are
+	// a GitLab Premium feature, or when the token may not read them.
+	Available bool
+}
+
+// The nested connections are not paginated, GitLab returns up to 100
+// rotations per schedule and participants per rotation.
+const oncallSchedulesQuery = `query($fullPath: ID!) {
+  project(fullPath: $fullPath) {
+    incidentManagementOncallSchedules {
+      nodes {
+        rotations {
+          nodes {
+            participants {
+              count
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type oncallSchedulesResponse struct {
+	Project *struct {
+		Schedules *struct {
+			Nodes []struct {
+				Rotations struct {
+					Nodes []struct {
+						Participants struct {
+							Count int `json:"count"`
+						} `json:"participants"`
+					} `json:"nodes"`
+				} `json:"rotations"`
+			} `json:"nodes"`
+		} `json:"incidentManagementOncallSchedules"`
+	} `json:"project"`
+}
+
+// getOncallScheduleCount counts the on-call schedules of the project and
+// their participants. The REST API does not expose on-call schedules, so
+// they are read through GraphQL.
+func getOncallScheduleCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (OncallScheduleCount, error) {
+	fullPath, err := projectFullPath(ctx, git, project.ID)
+	if err != nil {
+		return OncallScheduleCount{}, err
+	}
+
+	var schedules oncallSchedulesResponse
+	ok, err := doGraphQL(ctx, git, oncallSchedulesQuery, map[string]any{"fullPath": fullPath}, &schedules)
+	if err != nil {
+		return OncallScheduleCount{}, fmt.Errorf("failed to get on-call schedules for project %s: %w", project.ID, err)
+	}
+
+	result := schedules.Project
+	if !ok || result == nil || result.Schedules == nil {
+		return OncallScheduleCount{}, nil
+	}
+
+	count := OncallScheduleCount{Schedules: len(result.Schedules.Nodes), Available: true}
+	for _, schedule := range result.Schedules.Nodes {
+		for _, rotation := range schedule.Rotations.Nodes {
+			count.Participants += rotation.Participants.Count
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+var pushDeleteOnMismatch bool
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&pushDeleteOnMismatch, "push-delete-on-mismatch", false, "Delete the metrics of the last push from the Push Gateway when its job or grouping no longer matches the config (uses --state-file)")
+}
+
+// deleteOrphanedMetrics compares the last push of the state file against the
+// current config. Every push replaces all metrics of its grouping, so the
+// metrics of removed groups only stay behind when the job or grouping of
+// the push has changed as well; that old grouping is deleted.
+func deleteOrphanedMetrics(config *Config, grouping map[string]string, last *PushState) error {
+	if last == nil {
+		return nil
+	}
+
+	var removed []string
+	for _, id := range slices.Sorted(maps.Keys(last.Groups)) {
+		if !slices.ContainsFunc(config.Groups, func(group GroupConfig) bool { return group.ID == id }) {
+			removed = append(removed, id)
+		}
+	}
+
+	if last.Job == config.JobName && maps.Equal(last.Grouping, grouping) {
+		if len(removed) > 0 {
+			fmt.Printf("Metrics of removed groups %s are replaced by the next push\n", strings.Join(removed, ", "))
+		}
+		return nil
+	}
+
+	fmt.Printf("Deleting metrics of the last push with job %s and grouping %v from the Push Gateway\n", last.Job, last.Grouping)
+	pusher := newPusher(config, last.Job, last.Grouping)
+	if err := pusher.Delete(); err != nil {
+		return fmt.Errorf("failed to delete metrics of job %s: %w", last.Job, err)
+	}
+	return nil
+}
+
+// pushState records the metric names of every group in values.
+func pushState(job string, grouping map[string]string, values []metricValue) *PushState {
+	pushed := &PushState{Job: job, Grouping: grouping, Groups: map[string][]string{}}
+	for _, value := range values {
+		for _, label := range value.Labels {
+			if label.GetName() != "group_id" {
+				continue
+			}
+			names := pushed.Groups[label.GetValue()]
+			if !slices.Contains(names, value.Name) {
+				pushed.Groups[label.GetValue()] = append(names, value.Name)
+			}
+		}
+	}
+	return pushed
+}
@@ -0,0 +1,261 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	outputFormatGitHubActions = "github-actions"
+	outputFormatGitLabCI      = "gitlab-ci"
+)
+
+var outputFormats = []string{outputFormatGitHubActions, outputFormatGitLabCI}
+
+var (
+	outputFormat  string
+	dotenvFile    string
+	outputFile    string
+	compactOutput bool
+
+	exportOpenMetrics bool
+)
+
+func init() {
+	scrapeCmd.Flags().StringVar(&outputFormat, "output-format", "", "Additionally report warnings, errors and metric values for a CI system: "+strings.Join(outputFormats, ", ")+" (github-actions is enabled automatically when GITHUB_ACTIONS=true)")
+	scrapeCmd.Flags().StringVar(&outputFile, "output", "", "Also write the collected metrics in the Prometheus text format to this file")
+	scrapeCmd.Flags().BoolVar(&exportOpenMetrics, "export-openmetrics", false, "Write the --output file and serve --listen-address in the OpenMetrics format instead of the Prometheus text format")
+	scrapeCmd.Flags().BoolVar(&compactOutput, "compact-output", false, "Omit the # HELP and # TYPE lines from the --output file, some Prometheus versions reject files without TYPE lines")
+	scrapeCmd.Flags().StringVar(&dotenvFile, "dotenv-file", "gitlab_metrics.env", "File the gitlab-ci output format writes the metric values to, for use with artifacts:reports:dotenv")
+}
+
+func resolveOutputFormat() error {
+	if outputFormat == "" && os.Getenv("GITHUB_ACTIONS") == "true" {
+		outputFormat = outputFormatGitHubActions
+	}
+	if outputFormat != "" && !slices.Contains(outputFormats, outputFormat) {
+		return fmt.Errorf("unknown output format %q, expected one of %s", outputFormat, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+func warnf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if outputFormat == outputFormatGitHubActions {
+		fmt.Printf("::warning::%s\n", escapeWorkflowCommand(message))
+		return
+	}
+	fmt.Printf("Warning: %s\n", message)
+}
+
+// fatalf reports an error and exits.
+func fatalf(format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if outputFormat == outputFormatGitHubActions {
+		fmt.Printf("::error::%s\n", escapeWorkflowCommand(message))
+	} else {
+		fmt.Println(message)
+	}
+	os.Exit(1)
+}
+
+// escapeWorkflowCommand escapes the characters that would end a GitHub
+// Actions workflow command early.
+func escapeWorkflowCommand(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+type metricValue struct {
+	Name   string
+	Help   string
+	Labels []*dto.LabelPair
+	Value  float64
+}
+
+// gatherValues returns every gauge, counter and untyped sample of g, sorted
+// by metric name and labels.
+func gatherValues(g prometheus.Gatherer) ([]metricValue, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []metricValue
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			var value float64
+			switch {
+			case metric.GetGauge() != nil:
+				value = metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				value = metric.GetCounter().GetValue()
+			case metric.GetUntyped() != nil:
+				value = metric.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			values = append(values, metricValue{Name: family.GetName(), Help: family.GetHelp(), Labels: metric.GetLabel(), Value: value})
+		}
+	}
+	return values, nil
+}
+
+var invalidOutputNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// outputName is the metric name followed by its label values, sorted by label
+// name, so that every series gets a distinct variable name.
+func (v metricValue) outputName() string {
+	parts := []string{v.Name}
+	for _, label := range v.Labels {
+		parts = append(parts, invalidOutputNameChars.ReplaceAllString(label.GetValue(), "_"))
+	}
+	return strings.Join(parts, "_")
+}
+
+// writeOutput reports the collected metric values in the selected output
+// format.
+func writeOutput(g prometheus.Gatherer) error {
+	if outputFormat == "" {
+		return nil
+	}
+
+	values, err := gatherValues(g)
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	switch outputFormat {
+	case outputFormatGitHubActions:
+		return writeGitHubActionsOutput(values)
+	case outputFormatGitLabCI:
+		return writeDotenvOutput(dotenvFile, values)
+	}
+	return nil
+}
+
+// writeGitHubActionsOutput appends the values to $GITHUB_OUTPUT, falling back
+// to the deprecated set-output command on runners that do not provide it.
+func writeGitHubActionsOutput(values []metricValue) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		for _, value := range values {
+			fmt.Printf("::set-output name=%s::%v\n", value.outputName(), value.Value)
+		}
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open GitHub Actions output file: %w", err)
+	}
+	defer file.Close()
+
+	for _, value := range values {
+		if _, err := fmt.Fprintf(file, "%s=%v\n", value.outputName(), value.Value); err != nil {
+			return fmt.Errorf("failed to write GitHub Actions output file: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeDotenvOutput writes the values as upper case shell variables in the
+// dotenv format GitLab CI reads from artifacts:reports:dotenv.
+func writeDotenvOutput(path string, values []metricValue) error {
+	var b strings.Builder
+	for _, value := range values {
+		fmt.Fprintf(&b, "%s=%v\n", strings.ToUpper(value.outputName()), value.Value)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write dotenv file: %w", err)
+	}
+	fmt.Printf("Wrote %d metric values to %s\n", len(values), path)
+	return nil
+}
+
+// exportFormat is the format metrics are written and served in.
+func exportFormat() expfmt.Format {
+	if exportOpenMetrics {
+		return expfmt.NewFormat(expfmt.TypeOpenMetrics)
+	}
+	return expfmt.NewFormat(expfmt.TypeTextPlain)
+}
+
+// encodeMetrics writes families to w in format. OpenMetrics includes the
+// created timestamps of counters and ends with the # EOF marker the format
+// requires.
+func encodeMetrics(w io.Writer, format expfmt.Format, families []*dto.MetricFamily) error {
+	encoder := expfmt.NewEncoder(w, format, expfmt.WithCreatedLines())
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+	if closer, ok := encoder.(expfmt.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// writeMetricsFile writes the metrics of g in the export format. With
+// compact set, the comment lines are left out.
+func writeMetricsFile(g prometheus.Gatherer, path string, compact bool) error {
+	families, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	if compact {
+		w = &commentFilterWriter{w: file}
+	}
+	if err := encodeMetrics(w, exportFormat(), families); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// commentFilterWriter drops every line starting with # before passing the
+// remaining lines on to w, except for the # EOF marker of OpenMetrics.
+type commentFilterWriter struct {
+	w    io.Writer
+	line []byte
+}
+
+func (f *commentFilterWriter) Write(p []byte) (int, error) {
+	for rest := p; len(rest) > 0; {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			f.line = append(f.line, rest...)
+			break
+		}
+		f.line = append(f.line, rest[:i+1]...)
+		rest = rest[i+1:]
+
+		if !bytes.HasPrefix(f.line, []byte("#")) || bytes.Equal(f.line, []byte("# EOF\n")) {
+			if _, err := f.w.Write(f.line); err != nil {
+				return 0, err
+			}
+		}
+		f.line = f.line[:0]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,27 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// groupPanicked reports a panic recovered while scraping the group, with the
+// stack of the panicking goroutine, and returns the counter that records it.
+// It must be called from the deferred function that recovered the panic.
+func groupPanicked(config *Config, group GroupConfig, recovered any, report *StatusReport) prometheus.Collector {
+	fmt.Printf("Panic while scraping group %s: %v\n%s", group.ID, recovered, debug.Stack())
+	report.Add(group.ID, GroupPanicked, fmt.Errorf("panic: %v", recovered))
+
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "gitlab_scrape_panic_total",
+		Help:        "Number of panics recovered while scraping the GitLab group",
+		ConstLabels: mergeLabels(config.DefaultLabels, group.ExtraLabels, prometheus.Labels{"group_id": group.ID}),
+	})
+	counter.Inc()
+	return counter
+}
@@ -0,0 +1,56 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var pidFile string
+
+func init() {
+	scrapeCmd.Flags().StringVar(&pidFile, "pid-file", "", "Write the process ID to this file in daemon mode (requires --interval)")
+}
+
+// writePIDFile writes the PID of the scraper to path and returns a function
+// that removes it again. It fails if the file names a process that is still
+// running, so that a daemon is not started twice. A file left behind by a
+// crashed daemon is replaced.
+func writePIDFile(path string) (func(), error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read pid file: %w", err)
+	}
+	if err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && processRunning(pid) {
+			return nil, fmt.Errorf("already running with pid %d according to %s", pid, path)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write pid file: %w", err)
+	}
+	return func() { os.Remove(path) }, nil
+}
+
+// processRunning reports whether a process with the pid exists. Signal 0
+// checks for the process without signaling it. A process of another user
+// rejects the signal, but still exists.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
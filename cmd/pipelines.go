@@ -0,0 +1,376 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// maxFailedPipelinesToInspect caps the number of pipelines whose jobs are
+// listed, since every pipeline costs an additional API call.
+const maxFailedPipelinesToInspect = 50
+
+// getPipelineFailureBreakdown counts the failed jobs of the most recent
+// failed pipelines by their failure reason.
+func getPipelineFailureBreakdown(ctx context.Context, git *gitlab.Client, project ProjectConfig) (map[string]int, error) {
+	options := &gitlab.ListProjectPipelinesOptions{
+		Status:  gitlab.Ptr(gitlab.Failed),
+		OrderBy: gitlab.Ptr("updated_at"),
+		Sort:    gitlab.Ptr("desc"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: maxFailedPipelinesToInspect,
+		},
+	}
+	if windowDays := project.PipelineFailureBreakdown.WindowDays; windowDays > 0 {
+		options.UpdatedAfter = gitlab.Ptr(time.Now().AddDate(0, 0, -windowDays))
+	}
+
+	pipelines, _, err := git.Pipelines.ListProjectPipelines(project.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed pipelines for project %s: %w", project.ID, err)
+	}
+
+	reasons := map[string]int{}
+	for _, pipeline := range pipelines {
+		jobOptions := &gitlab.ListJobsOptions{
+			Scope: gitlab.Ptr([]gitlab.BuildStateValue{gitlab.Failed}),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+
+		for {
+			jobs, resp, err := git.Jobs.ListPipelineJobs(project.ID, pipeline.ID, jobOptions, gitlab.WithContext(ctx))
+			if err != nil {
+				return reasons, fmt.Errorf("failed to list failed jobs of pipeline %d for project %s: %w", pipeline.ID, project.ID, err)
+			}
+
+			for _, job := range jobs {
+				reason := job.FailureReason
+				if reason == "" {
+					reason = "unknown_failure"
+				}
+				reasons[reason]++
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			jobOptions.Page = resp.NextPage
+		}
+	}
+
+	return reasons, nil
+}
+
+const allPipelineStatuses = "all"
+
+// pipelineStatuses are the statuses counted with split_by_status.
+var pipelineStatuses = []gitlab.BuildStateValue{
+	gitlab.Created, gitlab.WaitingForResource, gitlab.Preparing, gitlab.Pending, gitlab.Running,
+	gitlab.Success, gitlab.Failed, gitlab.Canceled, gitlab.Skipped, gitlab.Manual, gitlab.Scheduled,
+}
+
+func (c *PipelineCountConfig) statuses() []string {
+	if c.SplitByStatus {
+		statuses := make([]string, len(pipelineStatuses))
+		for i, status := range pipelineStatuses {
+			statuses[i] = string(status)
+		}
+		return statuses
+	}
+	if c.Status == "" {
+		return []string{allPipelineStatuses}
+	}
+	return []string{c.Status}
+}
+
+// getPipelineCounts returns the number of pipelines of the project by status.
+// With split_by_status every status is counted by its own request, all of
+// them in parallel.
+func getPipelineCounts(ctx context.Context, git *gitlab.Client, project ProjectConfig) (map[string]int, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		counts = map[string]int{}
+		errs   []error
+	)
+	for _, status := range project.PipelineCount.statuses() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := getPipelineCount(ctx, git, project, status)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			counts[status] = count
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func getPipelineCount(ctx context.Context, git *gitlab.Client, project ProjectConfig, status string) (int, error) {
+	options := &gitlab.ListProjectPipelinesOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+	if status != allPipelineStatuses {
+		options.Status = gitlab.Ptr(gitlab.BuildStateValue(status))
+	}
+	if ref := project.PipelineCount.Ref; ref != "" {
+		options.Ref = gitlab.Ptr(ref)
+	}
+
+	_, resp, err := git.Pipelines.ListProjectPipelines(project.ID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s pipelines for project %s: %w", status, project.ID, err)
+	}
+	return resp.TotalItems, nil
+}
+
+// maxPipelineDurationsToInspect caps the number of pipelines of a project
+// whose duration is read, since the pipeline list does not include it and
+// every pipeline costs an additional API call.
+const maxPipelineDurationsToInspect = 50
+
+const (
+	defaultPipelineDurationStatus     = "success"
+	defaultPipelineDurationWindowDays = 7
+)
+
+func (c *PipelineDurationConfig) status() string {
+	if c.Status == "" {
+		return defaultPipelineDurationStatus
+	}
+	return c.Status
+}
+
+func (c *PipelineDurationConfig) aggregationFunc() string {
+	if c.AggregationFunc == "" {
+		return aggregateMax
+	}
+	return c.AggregationFunc
+}
+
+func (c *PipelineDurationConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultPipelineDurationWindowDays
+	}
+	return c.WindowDays
+}
+
+// PipelineDurations are the durations of the finished pipelines that were
+// inspected.
+type PipelineDurations struct {
+	Seconds []float64
+}
+
+func (d PipelineDurations) count() int { return len(d.Seconds) }
+
+func (d PipelineDurations) average() float64 { return aggregate(d.Seconds, aggregateAvg) }
+
+// getPipelineDurations reads the durations of the most recent pipelines of
+// the project with the configured status. Pipelines that have not finished
+// yet have no final duration and are left out.
+func getPipelineDurations(ctx context.Context, git *gitlab.Client, projectID string, config *PipelineDurationConfig) (PipelineDurations, error) {
+	options := &gitlab.ListProjectPipelinesOptions{
+		Status:       gitlab.Ptr(gitlab.BuildStateValue(config.status())),
+		UpdatedAfter: gitlab.Ptr(time.Now().AddDate(0, 0, -config.windowDays())),
+		OrderBy:      gitlab.Ptr("updated_at"),
+		Sort:         gitlab.Ptr("desc"),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: maxPipelineDurationsToInspect,
+		},
+	}
+
+	pipelines, _, err := git.Pipelines.ListProjectPipelines(projectID, options, gitlab.WithContext(ctx))
+	if err != nil {
+		return PipelineDurations{}, fmt.Errorf("failed to list pipelines for project %s: %w", projectID, err)
+	}
+
+	var durations PipelineDurations
+	for _, info := range pipelines {
+		pipeline, _, err := git.Pipelines.GetPipeline(projectID, info.ID, gitlab.WithContext(ctx))
+		if err != nil {
+			return PipelineDurations{}, fmt.Errorf("failed to get pipeline %d for project %s: %w", info.ID, projectID, err)
+		}
+		if pipeline.FinishedAt == nil {
+			continue
+		}
+		durations.Seconds = append(durations.Seconds, float64(pipeline.Duration))
+	}
+	return durations, nil
+}
+
+// getGroupPipelineDuration combines the average pipeline durations of every
+// project in the group and its subgroups with the configured aggregation
+// function. Projects without finished pipelines are left out. It also
+// returns the number of pipelines that were inspected.
+func getGroupPipelineDuration(ctx context.Context, git *gitlab.Client, group GroupConfig) (float64, int, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		averages  []float64
+		pipelines int
+	)
+	for _, project := range projects {
+		durations, err := getPipelineDurations(ctx, git, strconv.Itoa(project.ID), group.PipelineAvgDuration)
+		if err != nil {
+			return 0, 0, err
+		}
+		if durations.count() == 0 {
+			continue
+		}
+		averages = append(averages, durations.average())
+		pipelines += durations.count()
+	}
+	return aggregate(averages, group.PipelineAvgDuration.aggregationFunc()), pipelines, nil
+}
+
+const defaultFailedJobWithinHours = 24
+
+func (c *FailedJobConfig) withinHours() int {
+	if c.WithinHours <= 0 {
+		return defaultFailedJobWithinHours
+	}
+	return c.WithinHours
+}
+
+// getGroupFailedJobCount counts the failed jobs of every project of the
+// group and its subgroups created within within_hours by failure reason,
+// including retried jobs, whose failures a successful retry would hide from
+// the pipeline status. The jobs API has no time filter, but lists the
+// newest jobs first, so every project is only paged until the first job
+// that is too old. Projects whose jobs the token may not list, e.g. with
+// CI/CD disabled, are skipped. With failure_reason, the reason is reported
+// as 0 if no job failed for it.
+func getGroupFailedJobCount(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (map[string]int, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return nil, err
+	}
+
+	config := group.FailedJobCount
+	cutoff := now.Add(-time.Duration(config.withinHours()) * time.Hour)
+	reasons := map[string]int{}
+	for _, project := range projects {
+		options := &gitlab.ListJobsOptions{
+			Scope:          gitlab.Ptr([]gitlab.BuildStateValue{gitlab.Failed}),
+			IncludeRetried: gitlab.Ptr(true),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+
+	pages:
+		for {
+			jobs, resp, err := git.Jobs.ListProjectJobs(project.ID, options, gitlab.WithContext(ctx))
+			if isFeatureUnavailable(resp, err) {
+				fmt.Printf("Jobs of project %s are not available, skipping it\n", project.PathWithNamespace)
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list failed jobs for project %s: %w", project.PathWithNamespace, err)
+			}
+
+			for _, job := range jobs {
+				if job.CreatedAt != nil && job.CreatedAt.Before(cutoff) {
+					break pages
+				}
+				reason := cmp.Or(job.FailureReason, "unknown_failure")
+				if config.FailureReason == "" || config.FailureReason == reason {
+					reasons[reason]++
+				}
+			}
+
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
+		}
+	}
+	if _, ok := reasons[config.FailureReason]; !ok && config.FailureReason != "" {
+		reasons[config.FailureReason] = 0
+	}
+	return reasons, nil
+}
+
+const defaultTriggerUnusedDays = 90
+
+func (c *TriggerCountConfig) unusedDays() int {
+	if c.UnusedDays <= 0 {
+		return defaultTriggerUnusedDays
+	}
+	return c.UnusedDays
+}
+
+type PipelineTriggerCount struct {
+	Total int
+	Stale int
+	// Available is false when the token may not list the triggers, which
+	// requires the Maintainer role.
+	Available bool
+}
+
+// getPipelineTriggerCount counts the pipeline triggers of the project and the
+// stale ones among them, which were not used within unused_days days.
+// Triggers that were never used are stale once they are older than that.
+func getPipelineTriggerCount(ctx context.Context, git *gitlab.Client, project ProjectConfig, now time.Time) (PipelineTriggerCount, error) {
+	cutoff := now.AddDate(0, 0, -project.PipelineTriggerCount.unusedDays())
+	options := &gitlab.ListPipelineTriggersOptions{Page: 1, PerPage: 100}
+
+	count := PipelineTriggerCount{Available: true}
+	for {
+		triggers, resp, err := git.PipelineTriggers.ListPipelineTriggers(project.ID, options, gitlab.WithContext(ctx))
+		if isFeatureUnavailable(resp, err) {
+			return PipelineTriggerCount{}, nil
+		}
+		if err != nil {
+			return PipelineTriggerCount{}, fmt.Errorf("failed to list pipeline triggers for project %s: %w", project.ID, err)
+		}
+
+		for _, trigger := range triggers {
+			count.Total++
+			lastUsed := trigger.LastUsed
+			if lastUsed == nil {
+				lastUsed = trigger.CreatedAt
+			}
+			if lastUsed != nil && lastUsed.Before(cutoff) {
+				count.Stale++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+	return count, nil
+}
@@ -0,0 +1,79 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+var (
+	startupProbePush   bool
+	startupProbeGitLab bool
+)
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&startupProbePush, "startup-probe-push", false, "Push to the Push Gateway once on startup to verify it is reachable before scraping (default true with --interval)")
+	scrapeCmd.Flags().BoolVar(&startupProbeGitLab, "startup-probe-gitlab", false, "Request the GitLab version on startup to verify the URL and token before scraping (default true with --interval)")
+}
+
+// probeEnabled returns the value of a startup probe flag. Unless set
+// explicitly, probes run in daemon mode, where a misconfiguration would
+// otherwise only show up after the first scrape, but not for a single
+// scrape, which keeps failing on the first group as it always did.
+func probeEnabled(cmd *cobra.Command, flag string, value bool) bool {
+	if cmd.Flags().Changed(flag) {
+		return value
+	}
+	return interval > 0
+}
+
+// runStartupProbes runs the enabled startup probes before the first scrape.
+func runStartupProbes(cmd *cobra.Command, config *Config, accessToken string) error {
+	if probeEnabled(cmd, "startup-probe-gitlab", startupProbeGitLab) {
+		if err := probeGitLab(config, accessToken); err != nil {
+			return err
+		}
+	}
+	if probeEnabled(cmd, "startup-probe-push", startupProbePush) {
+		if err := probePushGateway(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// probeGitLab requests the GitLab version, which needs a valid token.
+func probeGitLab(config *Config, accessToken string) error {
+	git, err := newGitLabClient(config, accessToken)
+	if err != nil {
+		return fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+
+	version, _, err := git.Version.GetVersion()
+	var errResp *gitlab.ErrorResponse
+	if errors.As(err, &errResp) {
+		return fmt.Errorf("failed to reach GitLab at %s: status %d: %s", git.BaseURL(), errResp.Response.StatusCode, strings.TrimSpace(string(errResp.Body)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reach GitLab at %s: %w", git.BaseURL(), err)
+	}
+	fmt.Printf("GitLab at %s is reachable, version %s (%s)\n", git.BaseURL(), version.Version, version.Revision)
+	return nil
+}
+
+// probePushGateway pushes no metrics to the job and grouping of the scrape.
+// It uses POST rather than the PUT of a regular push, since an empty PUT
+// would delete the metrics of the last push until the first scrape is done.
+func probePushGateway(config *Config) error {
+	if err := newPusher(config, config.JobName, pushGrouping(config)).Add(); err != nil {
+		return fmt.Errorf("failed to reach the Push Gateway at %s: %w", config.PushGatewayURL, err)
+	}
+	fmt.Printf("Push Gateway at %s is reachable\n", config.PushGatewayURL)
+	return nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	profileEnable bool
+	cpuProfile    string
+	memProfile    string
+	blockProfile  string
+)
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&profileEnable, "profile-enable", false, "Enable the --cpu-profile, --mem-profile and --block-profile flags")
+	scrapeCmd.Flags().StringVar(&cpuProfile, "cpu-profile", "", "Write a CPU profile of the scrape to this file (requires --profile-enable)")
+	scrapeCmd.Flags().StringVar(&memProfile, "mem-profile", "", "Write a heap profile after the push to this file (requires --profile-enable)")
+	scrapeCmd.Flags().StringVar(&blockProfile, "block-profile", "", "Write a blocking profile of the scrape to this file (requires --profile-enable)")
+}
+
+// startProfiling starts the requested profiles and returns a function that
+// stops them and writes the results, to be called after the push.
+func startProfiling() func() {
+	if !profileEnable {
+		if cpuProfile != "" || memProfile != "" || blockProfile != "" {
+			warnf("profiling flags are ignored without --profile-enable")
+		}
+		return func() {}
+	}
+
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		var err error
+		cpuFile, err = os.Create(cpuProfile)
+		if err != nil {
+			fatalf("Failed to create CPU profile: %v", err)
+		}
+		if err := pprof.StartCPUProfile(cpuFile); err != nil {
+			fatalf("Failed to start CPU profile: %v", err)
+		}
+	}
+
+	if blockProfile != "" {
+		runtime.SetBlockProfileRate(1)
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if memProfile != "" {
+			runtime.GC()
+			writeProfile(memProfile, "heap", func(f *os.File) error { return pprof.WriteHeapProfile(f) })
+		}
+		if blockProfile != "" {
+			writeProfile(blockProfile, "block", func(f *os.File) error { return pprof.Lookup("block").WriteTo(f, 0) })
+		}
+	}
+}
+
+// withProfiling runs run between starting and stopping the requested
+// profiles, so that they are also written when run fails.
+func withProfiling(run func() error) error {
+	stopProfiling := startProfiling()
+	defer stopProfiling()
+	return run()
+}
+
+func writeProfile(path, name string, write func(*os.File) error) {
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Failed to create %s profile: %v\n", name, err)
+		return
+	}
+	defer f.Close()
+
+	if err := write(f); err != nil {
+		fmt.Printf("Failed to write %s profile: %v\n", name, err)
+	}
+}
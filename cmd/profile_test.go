@@ -0,0 +1,35 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedScrapeWritesProfiles(t *testing.T) {
+	previousEnable, previousCPU, previousMem := profileEnable, cpuProfile, memProfile
+	t.Cleanup(func() { profileEnable, cpuProfile, memProfile = previousEnable, previousCPU, previousMem })
+
+	dir := t.TempDir()
+	profileEnable = true
+	cpuProfile = filepath.Join(dir, "cpu.pprof")
+	memProfile = filepath.Join(dir, "mem.pprof")
+
+	failed := errors.New("scrape failed")
+	if err := withProfiling(func() error { return failed }); !errors.Is(err, failed) {
+		t.Fatalf("withProfiling returned %v, want the scrape error", err)
+	}
+	for _, path := range []string{cpuProfile, memProfile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("%s is empty", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// detectProjectEvents compares every configured project with what the state
+// file recorded in the previous scrape and reports the projects that were
+// transferred to another namespace or archived since then. The state file is
+// updated with the current values.
+func detectProjectEvents(ctx context.Context, git *gitlab.Client, config *Config) ([]prometheus.Collector, error) {
+	var collectors []prometheus.Collector
+	for _, project := range config.Projects {
+		p, _, err := git.Projects.GetProject(project.ID, nil, gitlab.WithContext(ctx))
+		if err != nil {
+			return collectors, fmt.Errorf("failed to get project %s: %w", project.ID, err)
+		}
+
+		if config.TrackProjectTransfers && p.Namespace != nil {
+			collectors = append(collectors, detectProjectTransfer(config, project, p.Namespace.FullPath)...)
+		}
+		if config.TrackProjectArchival {
+			collectors = append(collectors, detectProjectArchival(config, project, p.Archived))
+		}
+	}
+	return collectors, nil
+}
+
+func detectProjectTransfer(config *Config, project ProjectConfig, namespace string) []prometheus.Collector {
+	previous, ok := state.recordProjectNamespace(project.ID, namespace)
+	if !ok || previous == namespace {
+		return nil
+	}
+
+	fmt.Printf("Project %s was transferred from %s to %s\n", project.ID, previous, namespace)
+	labels := mergeLabels(config.DefaultLabels, prometheus.Labels{
+		"project_id":    project.ID,
+		"old_namespace": previous,
+		"new_namespace": namespace,
+	})
+	return []prometheus.Collector{
+		newGauge("gitlab_scrape_project_transfer_detected", "Set when the GitLab project moved to another namespace since the previous scrape", labels, 1),
+	}
+}
+
+// detectProjectArchival reports 1 in the scrape that first sees the project
+// archived and 0 otherwise.
+func detectProjectArchival(config *Config, project ProjectConfig, archived bool) prometheus.Collector {
+	previous, ok := state.recordProjectArchived(project.ID, archived)
+	event := 0.0
+	if ok && !previous && archived {
+		fmt.Printf("Project %s was archived\n", project.ID)
+		event = 1
+	}
+	labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"project_id": project.ID})
+	return newGauge("gitlab_scrape_project_archived_event", "Set when the GitLab project was archived since the previous scrape", labels, event)
+}
@@ -0,0 +1,535 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// groupProjectCache keeps the project list of every group for the duration
+// of a scrape, so that the metrics iterating the projects of a group share a
+// single listing. The mutex only guards the map; the listing of a group runs
+// once without it, while the other callers for that group wait for it.
+var groupProjectCache = struct {
+	sync.Mutex
+	projects map[string]*groupProjects
+}{projects: map[string]*groupProjects{}}
+
+// groupProjects is the listing of the projects of a group. done is closed
+// once projects and err are set.
+type groupProjects struct {
+	done     chan struct{}
+	projects []*gitlab.Project
+	err      error
+}
+
+func resetGroupProjectCache() {
+	groupProjectCache.Lock()
+	defer groupProjectCache.Unlock()
+	groupProjectCache.projects = map[string]*groupProjects{}
+	resetExcludedProjects()
+}
+
+// evictGroupProjects drops the cached project list of the group, so that the
+// next listGroupProjects lists the projects again.
+func evictGroupProjects(group GroupConfig) {
+	groupProjectCache.Lock()
+	defer groupProjectCache.Unlock()
+	delete(groupProjectCache.projects, group.ID)
+}
+
+// listGroupProjects returns the projects of the group and its subgroups
+// that are not excluded, at most max_projects_per_group of them. Callers
+// waiting for the same listing share its error, but a failed listing is not
+// cached, so the next caller lists the projects again.
+func listGroupProjects(ctx context.Context, git *gitlab.Client, group GroupConfig) ([]*gitlab.Project, error) {
+	groupProjectCache.Lock()
+	entry, ok := groupProjectCache.projects[group.ID]
+	if !ok {
+		entry = &groupProjects{done: make(chan struct{})}
+		groupProjectCache.projects[group.ID] = entry
+	}
+	groupProjectCache.Unlock()
+
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.projects, entry.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to list projects for group %s: %w", group.ID, ctx.Err())
+		}
+	}
+
+	entry.projects, entry.err = fetchGroupProjects(ctx, git, group)
+	if entry.err != nil {
+		groupProjectCache.Lock()
+		if groupProjectCache.projects[group.ID] == entry {
+			delete(groupProjectCache.projects, group.ID)
+		}
+		groupProjectCache.Unlock()
+	}
+	close(entry.done)
+	return entry.projects, entry.err
+}
+
+func fetchGroupProjects(ctx context.Context, git *gitlab.Client, group GroupConfig) ([]*gitlab.Project, error) {
+	// The simple view leaves out fields such as ci_config_path.
+	options := &gitlab.ListGroupProjectsOptions{
+		IncludeSubGroups: gitlab.Ptr(true),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var projects []*gitlab.Project
+	for {
+		page, resp, err := git.Groups.ListGroupProjects(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects for group %s: %w", group.ID, err)
+		}
+
+		for _, project := range page {
+			if !isExcludedProject(project) {
+				projects = append(projects, project)
+			}
+		}
+
+		if group.MaxProjectsPerGroup > 0 && len(projects) >= group.MaxProjectsPerGroup {
+			fmt.Printf("Group %s has more than %d projects, only the first %d are inspected\n", group.ID, group.MaxProjectsPerGroup, group.MaxProjectsPerGroup)
+			projects = projects[:group.MaxProjectsPerGroup]
+			break
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	return projects, nil
+}
+
+// getSharedProjectCount counts the projects shared with the group from other
+// namespaces, as the difference between the project count with and without
+// shared projects. GitLab versions that reject with_shared get the full
+// project count.
+func getSharedProjectCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
+	count := func(withShared *bool) (int, *gitlab.Response, error) {
+		_, resp, err := git.Groups.ListGroupProjects(group.ID, &gitlab.ListGroupProjectsOptions{
+			WithShared: withShared,
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 1,
+			},
+			Simple: gitlab.Ptr(true),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, resp, err
+		}
+		return resp.TotalItems, resp, nil
+	}
+
+	owned, resp, err := count(gitlab.Ptr(false))
+	if resp != nil && resp.StatusCode == http.StatusBadRequest {
+		all, _, err := count(nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list projects for group %s: %w", group.ID, err)
+		}
+		warnf("GitLab does not support with_shared, reporting all %d projects of group %s as shared", all, group.ID)
+		return all, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list owned projects for group %s: %w", group.ID, err)
+	}
+	all, _, err := count(gitlab.Ptr(true))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list projects for group %s: %w", group.ID, err)
+	}
+	return all - owned, nil
+}
+
+const defaultTopicTopN = 20
+
+func (c *TopicConfig) topN() int {
+	if c.TopN <= 0 {
+		return defaultTopicTopN
+	}
+	return c.TopN
+}
+
+type TopicCount struct {
+	Topic    string
+	Projects int
+}
+
+// getProjectTopicCount counts how many projects of the group and its
+// subgroups use every topic. Only the top_n most used topics are returned to
+// cap the cardinality, along with the number of projects without topics.
+func getProjectTopicCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (topics []TopicCount, withoutTopics int, err error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	counts := map[string]int{}
+	for _, project := range projects {
+		if len(project.Topics) == 0 {
+			withoutTopics++
+		}
+		for _, topic := range project.Topics {
+			counts[topic]++
+		}
+	}
+
+	for topic, count := range counts {
+		topics = append(topics, TopicCount{Topic: topic, Projects: count})
+	}
+	slices.SortFunc(topics, func(a, b TopicCount) int {
+		return cmp.Or(cmp.Compare(b.Projects, a.Projects), cmp.Compare(a.Topic, b.Topic))
+	})
+	if len(topics) > group.ProjectTopicCount.topN() {
+		fmt.Printf("Group %s uses %d topics, only the %d most used are reported\n", group.ID, len(topics), group.ProjectTopicCount.topN())
+		topics = topics[:group.ProjectTopicCount.topN()]
+	}
+	return topics, withoutTopics, nil
+}
+
+type READMEPresence struct {
+	With    int
+	Without int
+}
+
+// getREADMEPresenceCount counts the projects of the group and its subgroups
+// with and without a README.
+func getREADMEPresenceCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (READMEPresence, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return READMEPresence{}, err
+	}
+
+	var presence READMEPresence
+	for _, project := range projects {
+		if project.ReadmeURL != "" {
+			presence.With++
+		} else {
+			presence.Without++
+		}
+	}
+	return presence, nil
+}
+
+const defaultCIConfigPath = ".gitlab-ci.yml"
+
+type CIConfigPresence struct {
+	With    int
+	Without int
+}
+
+// getCIConfigPresenceCount counts the projects of the group and its
+// subgroups with and without a CI/CD configuration. ci_config_path is only
+// set for projects that moved the configuration away from .gitlab-ci.yml,
+// so for all others the file is looked up on the default branch.
+func getCIConfigPresenceCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (CIConfigPresence, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return CIConfigPresence{}, err
+	}
+
+	var presence CIConfigPresence
+	for _, project := range projects {
+		hasCI, err := hasCIConfig(ctx, git, project)
+		if err != nil {
+			return CIConfigPresence{}, err
+		}
+		if hasCI {
+			presence.With++
+		} else {
+			presence.Without++
+		}
+	}
+	return presence, nil
+}
+
+func hasCIConfig(ctx context.Context, git *gitlab.Client, project *gitlab.Project) (bool, error) {
+	if project.CIConfigPath != "" {
+		return true, nil
+	}
+	// Projects with an empty repository have no default branch.
+	if project.DefaultBranch == "" {
+		return false, nil
+	}
+
+	_, resp, err := git.RepositoryFiles.GetFileMetaData(project.ID, defaultCIConfigPath, &gitlab.GetFileMetaDataOptions{
+		Ref: gitlab.Ptr(project.DefaultBranch),
+	}, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up %s in project %s: %w", defaultCIConfigPath, project.PathWithNamespace, err)
+	}
+	return true, nil
+}
+
+// getGroupLastActivityAge returns the time since the most recently active
+// project of the group and its subgroups was last active, or +Inf if the
+// group has no projects. Projects are listed most recently active first, so
+// usually only the first one is read, unless it is excluded.
+func getGroupLastActivityAge(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (float64, error) {
+	options := &gitlab.ListGroupProjectsOptions{
+		IncludeSubGroups: gitlab.Ptr(true),
+		OrderBy:          gitlab.Ptr("last_activity_at"),
+		Sort:             gitlab.Ptr("desc"),
+		Simple:           gitlab.Ptr(true),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 1,
+		},
+	}
+
+	for {
+		projects, resp, err := git.Groups.ListGroupProjects(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list projects for group %s: %w", group.ID, err)
+		}
+
+		for _, project := range projects {
+			if isExcludedProject(project) || project.LastActivityAt == nil {
+				continue
+			}
+			return now.Sub(*project.LastActivityAt).Seconds(), nil
+		}
+
+		if resp.NextPage == 0 {
+			return math.Inf(1), nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+const defaultDormantDays = 365
+
+func (c *DormancyConfig) dormantDays() int {
+	if c.DormantDays <= 0 {
+		return defaultDormantDays
+	}
+	return c.DormantDays
+}
+
+// isGroupDormant reports whether no project of the group and its subgroups
+// was active within dormant_days days, along with the days since the last
+// activity, which are +Inf for a group without projects.
+func isGroupDormant(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (dormant bool, days float64, err error) {
+	age, err := getGroupLastActivityAge(ctx, git, group, now)
+	if err != nil {
+		return false, 0, err
+	}
+	days = age / (24 * time.Hour).Seconds()
+	return days >= float64(group.DormancyDetection.dormantDays()), days, nil
+}
+
+const defaultInactiveDays = 180
+
+func (c *InactivityConfig) inactiveDays() int {
+	if c.InactiveDays <= 0 {
+		return defaultInactiveDays
+	}
+	return c.InactiveDays
+}
+
+// InactiveProject is a project without activity for at least inactive_days.
+type InactiveProject struct {
+	Name string
+	// Age is the time since the last activity in seconds, +Inf for projects
+	// that GitLab reports no activity for.
+	Age float64
+}
+
+type InactiveProjects struct {
+	// Projects are the inactive projects, the longest inactive first.
+	Projects []InactiveProject
+	Total    int
+}
+
+// getInactiveProjectCount returns the projects of the group and its
+// subgroups that have not been active for inactive_days. GitLab updates the
+// last activity of a project on pushes, merge requests, issues and comments.
+func getInactiveProjectCount(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (InactiveProjects, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return InactiveProjects{}, err
+	}
+
+	cutoff := now.AddDate(0, 0, -group.ProjectInactivityAlert.inactiveDays())
+	inactive := InactiveProjects{Total: len(projects)}
+	for _, project := range projects {
+		switch {
+		case project.LastActivityAt == nil:
+			inactive.Projects = append(inactive.Projects, InactiveProject{Name: project.PathWithNamespace, Age: math.Inf(1)})
+		case project.LastActivityAt.Before(cutoff):
+			inactive.Projects = append(inactive.Projects, InactiveProject{Name: project.PathWithNamespace, Age: now.Sub(*project.LastActivityAt).Seconds()})
+		}
+	}
+	slices.SortFunc(inactive.Projects, func(a, b InactiveProject) int {
+		return cmp.Or(cmp.Compare(b.Age, a.Age), cmp.Compare(a.Name, b.Name))
+	})
+	return inactive, nil
+}
+
+var defaultProjectAgeBuckets = []int{1, 3}
+
+func (c *AgeDistConfig) buckets() []int {
+	if len(c.Buckets) == 0 {
+		return defaultProjectAgeBuckets
+	}
+	return c.Buckets
+}
+
+// ageBucketLabels names the age buckets bounded by the given years, e.g.
+// lt1yr, 1to3yr and gt3yr for 1 and 3.
+func ageBucketLabels(years []int) []string {
+	labels := []string{fmt.Sprintf("lt%dyr", years[0])}
+	for i := 1; i < len(years); i++ {
+		labels = append(labels, fmt.Sprintf("%dto%dyr", years[i-1], years[i]))
+	}
+	return append(labels, fmt.Sprintf("gt%dyr", years[len(years)-1]))
+}
+
+const defaultMaxProjectsToScan = 1000
+
+func (c *ProjectCountConfig) maxProjectsToScan() int {
+	if c.MaxProjectsToScan == 0 {
+		return defaultMaxProjectsToScan
+	}
+	return c.MaxProjectsToScan
+}
+
+func validateProjectCounts(groups []GroupConfig) error {
+	for _, group := range groups {
+		if group.ProjectCount == nil {
+			continue
+		}
+		if group.ProjectCount.MinStars < 0 {
+			return fmt.Errorf("project_count min_stars of group %s must not be negative, got %d", group.ID, group.ProjectCount.MinStars)
+		}
+		if group.ProjectCount.MaxProjectsToScan < 0 {
+			return fmt.Errorf("project_count max_projects_to_scan of group %s must not be negative, got %d", group.ID, group.ProjectCount.MaxProjectsToScan)
+		}
+	}
+	return nil
+}
+
+// getPopularProjectCount counts the projects with at least min_stars stars.
+// The API cannot filter by stars, so the projects are listed by star count,
+// most starred first, until one has fewer stars. Excluded projects are
+// skipped. At most max_projects_to_scan projects that are not excluded are
+// counted, in which case the count is a lower bound.
+func getPopularProjectCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
+	includeSubGroups := false
+	if group.ProjectCount.IncludeSubGroups != nil {
+		includeSubGroups = *group.ProjectCount.IncludeSubGroups
+	}
+	minStars := group.ProjectCount.MinStars
+	remaining := group.ProjectCount.maxProjectsToScan()
+
+	count, err := sumOverSubgroups(ctx, git, group, includeSubGroups, func(id string, includeSubGroups bool) (int, error) {
+		options := &gitlab.ListGroupProjectsOptions{
+			IncludeSubGroups: gitlab.Ptr(includeSubGroups),
+			OrderBy:          gitlab.Ptr("star_count"),
+			Sort:             gitlab.Ptr("desc"),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+			Simple: gitlab.Ptr(true),
+		}
+		count := 0
+		for remaining > 0 {
+			projects, resp, err := git.Groups.ListGroupProjects(id, options, gitlab.WithContext(ctx))
+			if err != nil {
+				return 0, fmt.Errorf("failed to list projects for group %s: %w", id, err)
+			}
+			for _, project := range projects {
+				if project.StarCount < minStars {
+					return count, nil
+				}
+				if isExcludedProject(project) {
+					continue
+				}
+				if remaining == 0 {
+					return count, nil
+				}
+				count++
+				remaining--
+			}
+			if resp.NextPage == 0 {
+				break
+			}
+			options.Page = resp.NextPage
+		}
+		return count, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if remaining == 0 {
+		warnf("stopped counting the popular projects of group %s after max_projects_to_scan projects", group.ID)
+	}
+	return count, nil
+}
+
+func validateProjectAgeBuckets(groups []GroupConfig) error {
+	for _, group := range groups {
+		if group.ProjectAgeDistribution == nil {
+			continue
+		}
+		buckets := group.ProjectAgeDistribution.Buckets
+		for i, years := range buckets {
+			if years <= 0 || (i > 0 && years <= buckets[i-1]) {
+				return fmt.Errorf("project_age_distribution buckets of group %s must be positive and ascending, got %v", group.ID, buckets)
+			}
+		}
+	}
+	return nil
+}
+
+// getProjectAgeDistribution counts the projects of the group and its
+// subgroups by the age bucket their creation date falls into. Every bucket
+// is reported, also if it is empty.
+func getProjectAgeDistribution(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) (map[string]int, error) {
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return nil, err
+	}
+
+	years := group.ProjectAgeDistribution.buckets()
+	labels := ageBucketLabels(years)
+	distribution := map[string]int{}
+	for _, label := range labels {
+		distribution[label] = 0
+	}
+	for _, project := range projects {
+		if project.CreatedAt == nil {
+			continue
+		}
+		bucket := len(years)
+		for i, limit := range years {
+			if project.CreatedAt.After(now.AddDate(-limit, 0, 0)) {
+				bucket = i
+				break
+			}
+		}
+		distribution[labels[bucket]]++
+	}
+	return distribution, nil
+}
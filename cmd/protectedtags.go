@@ -0,0 +1,50 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type ProtectedTagCount struct {
+	Total int
+	// Wildcard counts the protected tag patterns like v* that match more
+	// than a single tag name.
+	Wildcard int
+}
+
+// getProtectedTagCount counts the protected tags of the project. The
+// patterns are listed in full to tell the wildcard ones apart, which is a
+// single request for all but a few projects.
+func getProtectedTagCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (ProtectedTagCount, error) {
+	opt := &gitlab.ListProtectedTagsOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	var count ProtectedTagCount
+	for {
+		tags, resp, err := git.ProtectedTags.ListProtectedTags(project.ID, opt, gitlab.WithContext(ctx))
+		if err != nil {
+			return ProtectedTagCount{}, fmt.Errorf("failed to list protected tags for project %s: %w", project.ID, err)
+		}
+
+		for _, tag := range tags {
+			count.Total++
+			if strings.Contains(tag.Name, "*") {
+				count.Wildcard++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return count, nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	protectionCheckBranchProtection = "branch_protection"
+	protectionCheckPushRules        = "push_rules"
+	protectionCheckApprovals        = "approvals"
+	protectionCheckCIConfig         = "ci_config"
+)
+
+var protectionChecks = []string{protectionCheckBranchProtection, protectionCheckPushRules, protectionCheckApprovals, protectionCheckCIConfig}
+
+// weight returns the points the check adds to the score, 1 unless the
+// config sets another weight.
+func (c *ProtectionConfig) weight(check string) float64 {
+	if weight, ok := c.Weights[check]; ok {
+		return weight
+	}
+	return 1
+}
+
+func validateProtectionScores(projects []ProjectConfig) error {
+	for _, project := range projects {
+		if project.ProtectionScore == nil {
+			continue
+		}
+		if err := validateCheckWeights("protection_score", project.ID, project.ProtectionScore.Weights, protectionChecks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCheckWeights validates the weights of a score metric, which must
+// be known checks and not negative.
+func validateCheckWeights(key, projectID string, weights map[string]float64, checks []string) error {
+	for _, check := range slices.Sorted(maps.Keys(weights)) {
+		if !slices.Contains(checks, check) {
+			return fmt.Errorf("unknown %s weight %q of project %s, expected one of %s", key, check, projectID, strings.Join(checks, ", "))
+		}
+		if weight := weights[check]; weight < 0 {
+			return fmt.Errorf("%s weight %s of project %s must not be negative, got %v", key, check, projectID, weight)
+		}
+	}
+	return nil
+}
+
+type ProtectionScore struct {
+	Score float64
+	// Checks holds whether each protection check passed.
+	Checks map[string]bool
+}
+
+// getProjectProtectionScore adds up the weights of the protections the
+// project has in place: a protected default branch, push rules, approval
+// rules requiring at least one approval and a CI/CD configuration. Push
+// rules and approval rules are GitLab Premium features and count as missing
+// where they are not available.
+func getProjectProtectionScore(ctx context.Context, git *gitlab.Client, project ProjectConfig) (ProtectionScore, error) {
+	p, _, err := git.Projects.GetProject(project.ID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return ProtectionScore{}, fmt.Errorf("failed to get project %s: %w", project.ID, err)
+	}
+
+	checks := map[string]bool{}
+	// Projects with an empty repository have no default branch to protect.
+	if p.DefaultBranch != "" {
+		branch, _, err := git.Branches.GetBranch(p.ID, p.DefaultBranch, gitlab.WithContext(ctx))
+		if err != nil {
+			return ProtectionScore{}, fmt.Errorf("failed to get default branch of project %s: %w", project.ID, err)
+		}
+		checks[protectionCheckBranchProtection] = branch.Protected
+	}
+
+	rules, err := getProjectPushRules(ctx, git, project)
+	if err != nil {
+		return ProtectionScore{}, err
+	}
+	checks[protectionCheckPushRules] = rules.Enabled
+
+	approvalRules, resp, err := git.Projects.GetProjectApprovalRules(p.ID, nil, gitlab.WithContext(ctx))
+	if err != nil && !isFeatureUnavailable(resp, err) {
+		return ProtectionScore{}, fmt.Errorf("failed to get approval rules of project %s: %w", project.ID, err)
+	}
+	checks[protectionCheckApprovals] = slices.ContainsFunc(approvalRules, func(rule *gitlab.ProjectApprovalRule) bool {
+		return rule.ApprovalsRequired > 0
+	})
+
+	checks[protectionCheckCIConfig], err = hasCIConfig(ctx, git, p)
+	if err != nil {
+		return ProtectionScore{}, err
+	}
+
+	score := ProtectionScore{Checks: checks}
+	for _, check := range protectionChecks {
+		if checks[check] {
+			score.Score += project.ProtectionScore.weight(check)
+		}
+	}
+	return score, nil
+}
+
+// formatChecks lists whether each of checks passed, in their order.
+func formatChecks(checks []string, passed map[string]bool) string {
+	parts := make([]string, 0, len(checks))
+	for _, check := range checks {
+		parts = append(parts, fmt.Sprintf("%s: %t", check, passed[check]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type PushRules struct {
+	Enabled bool
+	// MaxFileSizeBytes is 0 if the size of pushed files is not limited.
+	MaxFileSizeBytes float64
+	// Available is false on instances without push rules, which are a
+	// GitLab Premium feature, or when the token may not read them.
+	Available bool
+}
+
+// pushRulesFromMaxFileSize converts the maximum file size of push rules,
+// which GitLab reports in MiB.
+func pushRulesFromMaxFileSize(id, maxFileSize int) PushRules {
+	return PushRules{Enabled: id != 0, MaxFileSizeBytes: float64(maxFileSize) * 1024 * 1024, Available: true}
+}
+
+// getProjectPushRules reads the push rules of the project. Projects without
+// push rules get an empty response.
+func getProjectPushRules(ctx context.Context, git *gitlab.Client, project ProjectConfig) (PushRules, error) {
+	rules, resp, err := git.Projects.GetProjectPushRules(project.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return PushRules{}, nil
+	}
+	if err != nil {
+		return PushRules{}, fmt.Errorf("failed to get push rules for project %s: %w", project.ID, err)
+	}
+	if rules == nil {
+		return PushRules{Available: true}, nil
+	}
+	return pushRulesFromMaxFileSize(rules.ID, rules.MaxFileSize), nil
+}
+
+func getGroupPushRules(ctx context.Context, git *gitlab.Client, group GroupConfig) (PushRules, error) {
+	rules, resp, err := git.Groups.GetGroupPushRules(group.ID, gitlab.WithContext(ctx))
+	if isFeatureUnavailable(resp, err) {
+		return PushRules{}, nil
+	}
+	if err != nil {
+		return PushRules{}, fmt.Errorf("failed to get push rules for group %s: %w", group.ID, err)
+	}
+	if rules == nil {
+		return PushRules{Available: true}, nil
+	}
+	return pushRulesFromMaxFileSize(rules.ID, rules.MaxFileSize), nil
+}
+
+func pushRulesCollectors(kind string, rules PushRules, labels prometheus.Labels) []prometheus.Collector {
+	if !rules.Available {
+		return []prometheus.Collector{featureUnavailableGauge("push_rules", labels)}
+	}
+	enabled := 0.0
+	if rules.Enabled {
+		enabled = 1
+	}
+	return []prometheus.Collector{
+		newGauge("gitlab_"+kind+"_push_rules_enabled", "Set to 1 if the GitLab "+kind+" has push rules", labels, enabled),
+		newGauge("gitlab_"+kind+"_push_rules_max_file_size_bytes", "Maximum size of files pushed to the GitLab "+kind+" in bytes, 0 if unlimited", labels, rules.MaxFileSizeBytes),
+	}
+}
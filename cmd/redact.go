@@ -0,0 +1,86 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultSensitiveFields are redacted from config output in addition to the
+// sensitive_fields of the config.
+var defaultSensitiveFields = []string{"access_token"}
+
+// redactConfig replaces the values at the sensitive field paths of the config
+// document data, in place, and returns it. A path names nested fields
+// separated by dots, where * matches every field of an object and every
+// element of a list, e.g. groups.*.access_token. Paths that do not exist in
+// data are ignored.
+func redactConfig(data map[string]interface{}, fields []string) map[string]interface{} {
+	for _, field := range slices.Concat(defaultSensitiveFields, fields) {
+		redactPath(data, strings.Split(field, "."))
+	}
+	return data
+}
+
+func redactPath(value any, path []string) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if len(path) == 1 {
+				value[key] = redacted
+			} else {
+				redactPath(field, path[1:])
+			}
+		}
+	case []any:
+		for i, item := range value {
+			if path[0] != "*" && path[0] != strconv.Itoa(i) {
+				continue
+			}
+			if len(path) == 1 {
+				value[i] = redacted
+			} else {
+				redactPath(item, path[1:])
+			}
+		}
+	}
+}
+
+// redactedConfig returns the config document of config with its sensitive
+// fields redacted, for printing the config.
+func redactedConfig(config *Config) map[string]any {
+	return redactConfig(configDocument(config), config.SensitiveFields)
+}
+
+// redactedIDs returns the IDs of the groups and projects of config mapped
+// to the form in which they may be printed, which is [REDACTED] where
+// sensitive_fields covers them, e.g. groups.*.id.
+func redactedIDs(config *Config) (groups, projects map[string]string) {
+	document := redactedConfig(config)
+	groups, projects = map[string]string{}, map[string]string{}
+	for i, group := range config.Groups {
+		groups[group.ID] = printedID(document["groups"], i, group.ID)
+	}
+	for i, project := range config.Projects {
+		projects[project.ID] = printedID(document["projects"], i, project.ID)
+	}
+	return groups, projects
+}
+
+func printedID(targets any, i int, id string) string {
+	items, _ := targets.([]any)
+	if i < len(items) {
+		if item, ok := items[i].(map[string]any); ok {
+			if printed, ok := item["id"].(string); ok {
+				return printed
+			}
+		}
+	}
+	return id
+}
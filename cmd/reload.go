@@ -0,0 +1,128 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	configWatch         bool
+	configWatchDebounce time.Duration
+)
+
+// configPollInterval is how often --config-watch checks the modification
+// time of the config file.
+const configPollInterval = time.Second
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&configWatch, "config-watch", false, "Reload the config file in daemon mode when it changes, it is also reloaded on SIGHUP")
+	scrapeCmd.Flags().DurationVar(&configWatchDebounce, "config-watch-debounce", 2*time.Second, "Reload the config only once the file changes and SIGHUPs stop for this long")
+}
+
+// configReloader swaps the config of the daemon when the config file is
+// changed. Reloads are debounced, since writing a file often shows up as
+// several changes in a row. The debounced reload is only requested on
+// requests; the daemon applies it between scrapes, since reading the config
+// changes the global viper state the scrape reads.
+type configReloader struct {
+	path     string
+	current  *Config
+	requests chan struct{}
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func newConfigReloader(path string, config *Config) *configReloader {
+	return &configReloader{path: path, current: config, requests: make(chan struct{}, 1)}
+}
+
+func (r *configReloader) Config() *Config {
+	return r.current
+}
+
+// trigger schedules a reload request after the debounce period, restarting
+// the period if a request is already scheduled.
+func (r *configReloader) trigger() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer == nil {
+		r.timer = time.AfterFunc(configWatchDebounce, r.request)
+		return
+	}
+	r.timer.Reset(configWatchDebounce)
+}
+
+// request asks for a reload, unless one is already pending.
+func (r *configReloader) request() {
+	select {
+	case r.requests <- struct{}{}:
+	default:
+	}
+}
+
+// reload keeps the previous config if the new one is invalid, so that a
+// broken edit does not stop the daemon. It must not run during a scrape.
+func (r *configReloader) reload() {
+	config, err := readConfig(r.path)
+	if err != nil {
+		warnf("failed to reload config, keeping the previous one: %v", err)
+		return
+	}
+	applyConnectionSettings(config)
+	r.current = config
+	fmt.Printf("Reloaded config from %s\n", r.path)
+}
+
+// watch triggers reloads on SIGHUP and, with --config-watch, whenever the
+// modification time of the config file changes, until ctx is done.
+func (r *configReloader) watch(ctx context.Context) {
+	if r.path == "-" {
+		if configWatch {
+			warnf("--config-watch is ignored for a config read from stdin")
+		}
+		return
+	}
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var poll <-chan time.Time
+	lastModified := modTime(r.path)
+	if configWatch {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			r.trigger()
+		case <-poll:
+			if modified := modTime(r.path); !modified.Equal(lastModified) {
+				lastModified = modified
+				r.trigger()
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(os.ExpandEnv(path))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
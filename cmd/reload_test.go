@@ -0,0 +1,45 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReloadIsRequestedAfterDebounce(t *testing.T) {
+	previousDebounce := configWatchDebounce
+	t.Cleanup(func() { configWatchDebounce = previousDebounce })
+	configWatchDebounce = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("push_gateway_url: http://pushgateway:9091\ngroups: []\nprojects: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	initial := &Config{}
+	reloader := newConfigReloader(path, initial)
+
+	reloader.trigger()
+	reloader.trigger()
+	select {
+	case <-reloader.requests:
+	case <-time.After(time.Second):
+		t.Fatal("no reload was requested")
+	}
+	if reloader.Config() != initial {
+		t.Fatal("config was reloaded before the request was applied")
+	}
+
+	reloader.reload()
+	if got := reloader.Config().PushGatewayURL; got != "http://pushgateway:9091" {
+		t.Errorf("push_gateway_url = %q, want the reloaded one", got)
+	}
+	select {
+	case <-reloader.requests:
+		t.Error("the debounced triggers requested more than one reload")
+	case <-time.After(5 * configWatchDebounce):
+	}
+}
@@ -0,0 +1,244 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// getCloneSize determines the size of the project's tar.gz archive without
+// downloading it. A HEAD request is tried first; if GitLab does not report a
+// Content-Length, a single byte range request is used and the total size is
+// read from the Content-Range header instead. If GitLab ignores the range,
+// the download is cancelled and the repository size of the project
+// statistics is reported instead.
+func getCloneSize(ctx context.Context, git *gitlab.Client, project ProjectConfig) (int64, error) {
+	options := &gitlab.ArchiveOptions{Format: gitlab.Ptr("tar.gz")}
+	if project.CloneSize.Branch != "" {
+		options.SHA = gitlab.Ptr(project.CloneSize.Branch)
+	}
+
+	u := fmt.Sprintf("projects/%s/repository/archive.tar.gz", gitlab.PathEscape(project.ID))
+	req, err := git.NewRequest(http.MethodHead, u, options, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive request for project %s: %w", project.ID, err)
+	}
+
+	resp, err := git.Do(req, nil)
+	if err == nil && resp.ContentLength >= 0 {
+		return resp.ContentLength, nil
+	}
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+
+	rangeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	resp, err = git.Repositories.StreamArchive(project.ID, &rangeWriter{cancel: cancel}, options, gitlab.WithHeader("Range", "bytes=0-0"), gitlab.WithContext(rangeCtx))
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if resp != nil && resp.StatusCode == http.StatusPartialContent {
+		contentRange := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			if size, err := strconv.ParseInt(contentRange[i+1:], 10, 64); err == nil {
+				return size, nil
+			}
+		}
+	}
+	if err != nil && !errors.Is(err, errRangeIgnored) && !errors.Is(err, context.Canceled) {
+		return 0, fmt.Errorf("failed to get archive size for project %s: %w", project.ID, err)
+	}
+
+	p, _, err := git.Projects.GetProject(project.ID, &gitlab.GetProjectOptions{Statistics: gitlab.Ptr(true)}, gitlab.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get repository size for project %s: %w", project.ID, err)
+	}
+	if p.Statistics == nil {
+		return 0, fmt.Errorf("GitLab did not report the archive size for project %s", project.ID)
+	}
+	return p.Statistics.RepositorySize, nil
+}
+
+var errRangeIgnored = errors.New("GitLab ignored the range of the archive request")
+
+// rangeWriter receives the body of a single byte range request. More than
+// one byte means that the range was ignored and the whole archive is being
+// sent, so the request is cancelled.
+type rangeWriter struct {
+	written int
+	cancel  context.CancelFunc
+}
+
+func (w *rangeWriter) Write(p []byte) (int, error) {
+	w.written += len(p)
+	if w.written > 1 {
+		w.cancel()
+		return 0, errRangeIgnored
+	}
+	return len(p), nil
+}
+
+const defaultMaxFilesToCount = 10000
+
+// getFileCount counts the files below the configured path. Without MaxDepth
+// the tree is listed recursively by GitLab; with MaxDepth directories are
+// expanded level by level until the depth is reached. Counting stops at
+// MaxFilesToCount files; truncated is only true if the tree has a file
+// beyond that, so a tree of exactly MaxFilesToCount files is complete.
+func getFileCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (count int, truncated bool, err error) {
+	config := project.FileCount
+	maxFiles := config.MaxFilesToCount
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxFilesToCount
+	}
+
+	recursive := config.MaxDepth <= 0
+	directories := []string{config.Path}
+	for depth := 1; len(directories) > 0 && !truncated; depth++ {
+		var next []string
+		for _, directory := range directories {
+			err := listTree(ctx, git, project, directory, recursive, func(node *gitlab.TreeNode) bool {
+				switch node.Type {
+				case "blob":
+					if count >= maxFiles {
+						truncated = true
+						return false
+					}
+					count++
+				case "tree":
+					if !recursive && depth < config.MaxDepth {
+						next = append(next, node.Path)
+					}
+				}
+				return true
+			})
+			if err != nil {
+				return count, false, err
+			}
+			if truncated {
+				break
+			}
+		}
+		directories = next
+	}
+
+	return count, truncated, nil
+}
+
+// listTree pages through the repository tree at path and calls visit for
+// every node until visit returns false.
+func listTree(ctx context.Context, git *gitlab.Client, project ProjectConfig, path string, recursive bool, visit func(*gitlab.TreeNode) bool) error {
+	options := &gitlab.ListTreeOptions{
+		Recursive: gitlab.Ptr(recursive),
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	if path != "" {
+		options.Path = gitlab.Ptr(path)
+	}
+	if project.FileCount.Ref != "" {
+		options.Ref = gitlab.Ptr(project.FileCount.Ref)
+	}
+
+	for {
+		nodes, resp, err := git.Repositories.ListTree(project.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("failed to list repository tree for project %s: %w", project.ID, err)
+		}
+
+		for _, node := range nodes {
+			if !visit(node) {
+				return nil
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return nil
+		}
+		options.Page = resp.NextPage
+	}
+}
+
+type ContributorDomainStats struct {
+	Internal int
+	External int
+}
+
+// getContributorDomainStats classifies every contributor of the project by
+// the domain of their commit email. Only domains are ever logged so that
+// contributor addresses do not end up in scrape logs.
+func getContributorDomainStats(ctx context.Context, git *gitlab.Client, project ProjectConfig) (ContributorDomainStats, error) {
+	options := &gitlab.ListContributorsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	var stats ContributorDomainStats
+	externalDomains := map[string]int{}
+	for {
+		contributors, resp, err := git.Repositories.Contributors(project.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return stats, fmt.Errorf("failed to list contributors for project %s: %w", project.ID, err)
+		}
+
+		for _, contributor := range contributors {
+			domain := emailDomain(contributor.Email)
+			if isInternalDomain(domain, project.ContributorDomainStats.InternalDomains) {
+				stats.Internal++
+			} else {
+				stats.External++
+				externalDomains[domain]++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	for domain, count := range externalDomains {
+		if domain == "" {
+			domain = "<none>"
+		}
+		fmt.Printf("External contributors in project %s from domain %s: %d\n", project.ID, domain, count)
+	}
+
+	return stats, nil
+}
+
+func emailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 {
+		return ""
+	}
+	return strings.ToLower(email[i+1:])
+}
+
+// isInternalDomain reports whether domain is one of the internal domains or
+// a subdomain of one of them.
+func isInternalDomain(domain string, internalDomains []string) bool {
+	if domain == "" {
+		return false
+	}
+	for _, internal := range internalDomains {
+		internal = strings.ToLower(internal)
+		if domain == internal || strings.HasSuffix(domain, "."+internal) {
+			return true
+		}
+	}
+	return false
+}
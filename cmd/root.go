@@ -18,6 +18,10 @@ examples and usage of using your application. For example:
 Cobra is a CLI library for Go that empowers applications.
 This application is a tool to generate the needed files
 to quickly create a Cobra application.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		bindEnv()
+		warnUnknownEnv()
+	},
 }
 
 func Execute() {
@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	labelSanitizerStrict  = "strict"
+	labelSanitizerReplace = "replace"
+	labelSanitizerEncode  = "encode"
+)
+
+var labelSanitizers = []string{labelSanitizerStrict, labelSanitizerReplace, labelSanitizerEncode}
+
+func validateLabelSanitizer(mode string) error {
+	if mode != "" && !slices.Contains(labelSanitizers, mode) {
+		return fmt.Errorf("unknown label_sanitizer %q, expected one of %s", mode, strings.Join(labelSanitizers, ", "))
+	}
+	return nil
+}
+
+// isLabelValueChar reports whether r may appear in a sanitized label value,
+// which is restricted to the characters of label names. Prometheus itself
+// accepts any UTF-8 label value, but some downstream systems do not.
+func isLabelValueChar(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+// sanitizeLabelValue handles the characters of value other than letters,
+// digits and underscores by mode: strict fails, replace replaces each with
+// an underscore and encode percent-encodes its UTF-8 bytes.
+func sanitizeLabelValue(value, mode string) (string, error) {
+	if !strings.ContainsFunc(value, func(r rune) bool { return !isLabelValueChar(r) }) {
+		return value, nil
+	}
+
+	var sanitized strings.Builder
+	for _, r := range value {
+		if isLabelValueChar(r) {
+			sanitized.WriteRune(r)
+			continue
+		}
+		switch mode {
+		case labelSanitizerStrict:
+			return "", fmt.Errorf("label value %q contains the invalid character %q", value, r)
+		case labelSanitizerReplace:
+			sanitized.WriteByte('_')
+		case labelSanitizerEncode:
+			for _, b := range []byte(string(r)) {
+				fmt.Fprintf(&sanitized, "%%%02X", b)
+			}
+		}
+	}
+	return sanitized.String(), nil
+}
+
+// sanitizeLabels applies sanitizeLabelValue to the value of every label. The
+// label pairs are shared with the collectors that wrote them and are
+// therefore replaced instead of changed in place.
+func sanitizeLabels(mode string) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				labels := make([]*dto.LabelPair, 0, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					value, err := sanitizeLabelValue(label.GetValue(), mode)
+					if err != nil {
+						return nil, fmt.Errorf("label %s of metric %s: %w", label.GetName(), family.GetName(), err)
+					}
+					labels = append(labels, &dto.LabelPair{Name: label.Name, Value: &value})
+				}
+				metric.Label = labels
+			}
+		}
+		return families, nil
+	}
+}
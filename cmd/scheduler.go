@@ -0,0 +1,269 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const defaultGroupWeight = 1.0
+
+const (
+	scrapeOrderNatural = "natural"
+	scrapeOrderShuffle = "shuffle"
+	scrapeOrderAlpha   = "alpha"
+	scrapeOrderWeight  = "weight"
+)
+
+var scrapeOrderModes = []string{scrapeOrderNatural, scrapeOrderShuffle, scrapeOrderAlpha, scrapeOrderWeight}
+
+var (
+	scrapeOrderMode string
+	scrapeOrderSeed uint64
+	// scrapeOrderRand is seeded once, so that every scrape of a daemon gets a
+	// new order while a seeded run stays reproducible.
+	scrapeOrderRand *rand.Rand
+)
+
+func init() {
+	scrapeCmd.Flags().StringVar(&scrapeOrderMode, "scrape-order", scrapeOrderNatural, "Order groups are scraped in: natural (config order), shuffle, alpha (by group ID) or weight (scrape_order, then weight, which the other modes ignore)")
+	scrapeCmd.Flags().Uint64Var(&scrapeOrderSeed, "scrape-order-seed", 0, "Seed for --scrape-order shuffle, random if not set")
+}
+
+func validateScrapeOrder() error {
+	if !slices.Contains(scrapeOrderModes, scrapeOrderMode) {
+		return fmt.Errorf("unknown --scrape-order %q, expected one of %s", scrapeOrderMode, strings.Join(scrapeOrderModes, ", "))
+	}
+	if scrapeOrderSeed != 0 {
+		scrapeOrderRand = rand.New(rand.NewPCG(scrapeOrderSeed, scrapeOrderSeed))
+	}
+	return nil
+}
+
+// warnIgnoredGroupWeights warns about groups setting scrape_order or weight,
+// which only --scrape-order weight takes into account.
+func warnIgnoredGroupWeights(groups []GroupConfig) {
+	if scrapeOrderMode == scrapeOrderWeight {
+		return
+	}
+	for _, group := range groups {
+		if group.ScrapeOrder != 0 || group.Weight != 0 {
+			warnf("scrape_order and weight of group %s are ignored with --scrape-order %s, use --scrape-order weight", group.ID, scrapeOrderMode)
+		}
+	}
+}
+
+// groupOrder returns the position of every group in the queue for the
+// --scrape-order modes that do not order by weight.
+func groupOrder(groups []GroupConfig) []int {
+	order := make([]int, len(groups))
+	switch scrapeOrderMode {
+	case scrapeOrderShuffle:
+		if scrapeOrderRand != nil {
+			order = scrapeOrderRand.Perm(len(groups))
+		} else {
+			order = rand.Perm(len(groups))
+		}
+	case scrapeOrderAlpha:
+		indices := make([]int, len(groups))
+		for i := range indices {
+			indices[i] = i
+		}
+		slices.SortStableFunc(indices, func(a, b int) int { return strings.Compare(groups[a].ID, groups[b].ID) })
+		for position, i := range indices {
+			order[i] = position
+		}
+	default:
+		for i := range order {
+			order[i] = i
+		}
+	}
+	return order
+}
+
+func (g GroupConfig) weight() float64 {
+	if g.Weight == 0 {
+		return defaultGroupWeight
+	}
+	return g.Weight
+}
+
+type queuedGroup struct {
+	group GroupConfig
+	// order is the position of the group in the config file, used to keep
+	// groups of equal weight in config file order, or its position in the
+	// --scrape-order.
+	order int
+}
+
+// groupQueue is a heap of groups ordered by scrape_order, lowest first, and
+// then by weight, highest first. Other --scrape-order modes only use the
+// order of the groups.
+type groupQueue []queuedGroup
+
+func (q groupQueue) Len() int { return len(q) }
+
+func (q groupQueue) Less(i, j int) bool {
+	if scrapeOrderMode != scrapeOrderWeight {
+		return q[i].order < q[j].order
+	}
+	if q[i].group.ScrapeOrder != q[j].group.ScrapeOrder {
+		return q[i].group.ScrapeOrder < q[j].group.ScrapeOrder
+	}
+	if q[i].group.weight() != q[j].group.weight() {
+		return q[i].group.weight() > q[j].group.weight()
+	}
+	return q[i].order < q[j].order
+}
+
+func (q groupQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *groupQueue) Push(x any) { *q = append(*q, x.(queuedGroup)) }
+
+func (q *groupQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// validateGroupDependencies returns an error if a group depends on a group
+// that is not configured or if the depends_on edges form a cycle.
+func validateGroupDependencies(groups []GroupConfig) error {
+	ids := map[string]bool{}
+	for _, group := range groups {
+		ids[group.ID] = true
+	}
+
+	pending := map[string]int{}
+	dependents := map[string][]string{}
+	for _, group := range groups {
+		for _, dependency := range group.DependsOn {
+			if !ids[dependency] {
+				return fmt.Errorf("group %s depends on group %s, which is not configured", group.ID, dependency)
+			}
+			pending[group.ID]++
+			dependents[dependency] = append(dependents[dependency], group.ID)
+		}
+	}
+
+	// Kahn's algorithm: whatever cannot be resolved is part of a cycle.
+	var ready []string
+	for _, group := range groups {
+		if pending[group.ID] == 0 {
+			ready = append(ready, group.ID)
+		}
+	}
+	resolved := 0
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		resolved++
+		for _, dependent := range dependents[id] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if resolved < len(groups) {
+		var cyclic []string
+		for _, group := range groups {
+			if pending[group.ID] > 0 {
+				cyclic = append(cyclic, group.ID)
+			}
+		}
+		return fmt.Errorf("depends_on of groups %s forms a cycle", strings.Join(cyclic, ", "))
+	}
+	return nil
+}
+
+// scrapeGroups runs scrapeGroup for every group on a pool of concurrency
+// workers, handing out the groups in queue order. A group is only
+// handed out once all groups in its depends_on have been scraped. Once ctx is
+// done no further groups are started; the groups that were never started are
+// returned, ready groups in queue order followed by waiting groups in config
+// order.
+func scrapeGroups(ctx context.Context, concurrency int, groups []GroupConfig, scrapeGroup func(GroupConfig)) []GroupConfig {
+	queue := make(groupQueue, 0, len(groups))
+	pending := map[string]int{}
+	dependents := map[string][]queuedGroup{}
+	order := groupOrder(groups)
+	for i, group := range groups {
+		queued := queuedGroup{group: group, order: order[i]}
+		if len(group.DependsOn) == 0 {
+			queue = append(queue, queued)
+			continue
+		}
+		pending[group.ID] = len(group.DependsOn)
+		for _, dependency := range group.DependsOn {
+			dependents[dependency] = append(dependents[dependency], queued)
+		}
+	}
+	heap.Init(&queue)
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	running := 0
+	next := func() (GroupConfig, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Wait for running groups to release their dependents.
+		for queue.Len() == 0 && running > 0 && ctx.Err() == nil {
+			cond.Wait()
+		}
+		if ctx.Err() != nil || queue.Len() == 0 {
+			return GroupConfig{}, false
+		}
+		running++
+		return heap.Pop(&queue).(queuedGroup).group, true
+	}
+	done := func(group GroupConfig) {
+		mu.Lock()
+		defer mu.Unlock()
+		running--
+		for _, dependent := range dependents[group.ID] {
+			pending[dependent.group.ID]--
+			if pending[dependent.group.ID] == 0 {
+				delete(pending, dependent.group.ID)
+				heap.Push(&queue, dependent)
+			}
+		}
+		cond.Broadcast()
+	}
+
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				group, ok := next()
+				if !ok {
+					return
+				}
+				scrapeGroup(group)
+				done(group)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var skipped []GroupConfig
+	for queue.Len() > 0 {
+		skipped = append(skipped, heap.Pop(&queue).(queuedGroup).group)
+	}
+	for _, group := range groups {
+		if pending[group.ID] > 0 {
+			skipped = append(skipped, group)
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+func validateScheduledPipelineCounts(projects []ProjectConfig) error {
+	for _, project := range projects {
+		if config := project.ScheduledPipelineCount; config != nil && config.Active != nil && config.SplitByStatus {
+			return fmt.Errorf("scheduled_pipeline_count of project %s cannot set both active and split_by_status", project.ID)
+		}
+	}
+	return nil
+}
+
+// listPipelineSchedulesOptions are the query parameters of the pipeline
+// schedules endpoint. client-go does not support the scope filter.
+type listPipelineSchedulesOptions struct {
+	gitlab.ListOptions
+	Scope *string `url:"scope,omitempty"`
+}
+
+func listPipelineSchedules(ctx context.Context, git *gitlab.Client, projectID, scope string, opt gitlab.ListOptions) ([]*gitlab.PipelineSchedule, *gitlab.Response, error) {
+	options := &listPipelineSchedulesOptions{ListOptions: opt}
+	if scope != "" {
+		options.Scope = gitlab.Ptr(scope)
+	}
+
+	u := fmt.Sprintf("projects/%s/pipeline_schedules", gitlab.PathEscape(projectID))
+	req, err := git.NewRequest(http.MethodGet, u, options, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var schedules []*gitlab.PipelineSchedule
+	resp, err := git.Do(req, &schedules)
+	return schedules, resp, err
+}
+
+type ScheduledPipelineCount struct {
+	Active   int
+	Inactive int
+	// NextRunSeconds is the time until the next run of an active schedule,
+	// +Inf if there is none.
+	NextRunSeconds float64
+}
+
+// getScheduledPipelineCount counts the pipeline schedules of the project.
+// Every active schedule is listed to find the next run, while the inactive
+// ones are only counted through the pagination headers, so a single one is
+// requested, and only if the config needs them.
+func getScheduledPipelineCount(ctx context.Context, git *gitlab.Client, project ProjectConfig, now time.Time) (ScheduledPipelineCount, error) {
+	config := project.ScheduledPipelineCount
+	count := ScheduledPipelineCount{NextRunSeconds: math.Inf(1)}
+
+	opt := gitlab.ListOptions{Page: 1, PerPage: 100}
+	for {
+		schedules, resp, err := listPipelineSchedules(ctx, git, project.ID, "active", opt)
+		if err != nil {
+			return ScheduledPipelineCount{}, fmt.Errorf("failed to list active pipeline schedules for project %s: %w", project.ID, err)
+		}
+
+		for _, schedule := range schedules {
+			count.Active++
+			if schedule.NextRunAt != nil {
+				count.NextRunSeconds = min(count.NextRunSeconds, max(schedule.NextRunAt.Sub(now).Seconds(), 0))
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if config.Active == nil || !*config.Active {
+		_, resp, err := listPipelineSchedules(ctx, git, project.ID, "inactive", gitlab.ListOptions{Page: 1, PerPage: 1})
+		if err != nil {
+			return ScheduledPipelineCount{}, fmt.Errorf("failed to list inactive pipeline schedules for project %s: %w", project.ID, err)
+		}
+		count.Inactive = resp.TotalItems
+	}
+	return count, nil
+}
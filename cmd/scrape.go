@@ -4,11 +4,15 @@ Copyright © 2025 Alexander Padberg <undefinedhuman>
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
+	"slices"
+	"sync"
+	"time"
 
-	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
 	"github.com/spf13/cobra"
@@ -20,157 +24,453 @@ var (
 	configFile     string
 	accessToken    string
 	pushGatewayURL string
-)
+	gitlabURL      string
+	pushJobPrefix  string
+	pushJobSuffix  string
+	skipCloneSize  bool
 
-type ProjectCountConfig struct {
-	IncludeSubGroups *bool `json:"include_subgroups,omitempty"`
-}
+	skipSSLVerify         bool
+	pushGatewaySkipVerify bool
 
-type MemberCountConfig struct{}
+	concurrency int
 
-type GroupConfig struct {
-	ID           string              `json:"id"`
-	ProjectCount *ProjectCountConfig `json:"project_count,omitempty"`
-	MemberCount  *MemberCountConfig  `json:"member_count,omitempty"`
-}
+	histogramBuckets []float64
+)
 
-type Config struct {
-	DefaultLabels map[string]string `json:"default_labels"`
-	Groups        []GroupConfig     `json:"groups"`
-}
+const defaultJobName = "gitlab_scrape"
+
+// defaultHistogramBuckets suit pipelines, which mostly take between a minute
+// and an hour, unlike prometheus.DefBuckets which end at ten seconds.
+var defaultHistogramBuckets = []float64{60, 120, 300, 600, 900, 1800, 3600}
 
 var scrapeCmd = &cobra.Command{
 	Use:   "scrape",
 	Short: "Scrape statisticsfrom GitLab",
 	Long:  `This command scrapes statisticsfrom from GitLab based on the provided configuration file.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		viper.SetConfigFile(configFile)
-		if err := viper.ReadInConfig(); err != nil {
-			fmt.Printf("Failed to read config file: %v\n", err)
-			os.Exit(1)
+		if err := resolveOutputFormat(); err != nil {
+			fatalf("%v", err)
+		}
+		config := loadConfig(configFile)
+		if err := validateMetricsFilter(); err != nil {
+			fatalf("%v", err)
+		}
+		if err := validateLabelPrefix(); err != nil {
+			fatalf("%v", err)
+		}
+		if err := validateHistogramBuckets(); err != nil {
+			fatalf("%v", err)
+		}
+		if err := validateScrapeOrder(); err != nil {
+			fatalf("%v", err)
+		}
+		if err := validateLogFormat(cmd.Flags().Changed("log-format")); err != nil {
+			fatalf("%v", err)
+		}
+		if err := validateMetricTimestamp(); err != nil {
+			fatalf("%v", err)
+		}
+
+		getRequiredValue("push_gateway_url",
+			"Please provide a Push Gateway URL using the --pushgateway flag or GITLAB_SCRAPER_PUSH_GATEWAY_URL environment variable")
+		viper.SetDefault("job_name", defaultJobName)
+		applyConnectionSettings(config)
+		warnIgnoredGroupWeights(config.Groups)
+
+		if snapshotReplay != "" {
+			if err := replaySnapshot(config, snapshotReplay); err != nil {
+				fatalf("Failed to replay snapshot: %v", err)
+			}
+			return
 		}
+		accessToken := resolveAccessToken(config)
 
-		accessToken := getRequiredValue("access_token", "GITLAB_ACCESS_TOKEN",
-			"Please provide an access token using the --token flag or GITLAB_ACCESS_TOKEN environment variable")
-		pushGatewayURL := getRequiredValue("push_gateway_url", "PUSHGATEWAY_URL",
-			"Please provide a Push Gateway URL using the --pushgateway flag or PUSHGATEWAY_URL environment variable")
+		if writePrometheusConfig != "" {
+			if err := writePrometheusScrapeConfig(writePrometheusConfig, config); err != nil {
+				fatalf("Failed to write Prometheus config: %v", err)
+			}
+			return
+		}
+		if listenAddress == "" {
+			listenAddress = config.ListenAddr
+		}
+		if listenAddress != "" && interval == 0 {
+			warnf("--listen-address is ignored without --interval")
+		}
+		if pidFile != "" && interval == 0 {
+			warnf("--pid-file is ignored without --interval")
+		}
 
-		var config Config
-		err := viper.Unmarshal(&config, func(dc *mapstructure.DecoderConfig) {
-			dc.TagName = "json"
+		if pushDeleteOnMismatch {
+			loadStateIfEnabled(config)
+			if err := deleteOrphanedMetrics(config, pushGrouping(config), state.LastPush); err != nil {
+				fatalf("Failed to delete orphaned metrics: %v", err)
+			}
+		}
+
+		if err := runStartupProbes(cmd, config, accessToken); err != nil {
+			fatalf("Startup probe failed: %v", err)
+		}
+
+		err := withProfiling(func() error {
+			if interval > 0 {
+				runDaemon(cmd.Context(), config, accessToken)
+				return nil
+			}
+			_, err := scrape(config, accessToken)
+			return err
 		})
 		if err != nil {
-			fmt.Printf("Failed to unmarshal config: %v\n", err)
-			os.Exit(1)
+			fatalf("Scrape failed: %v", err)
 		}
-
-		scrape(&config, accessToken, pushGatewayURL)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(scrapeCmd)
-	scrapeCmd.Flags().StringVarP(&configFile, "config", "c", "", "config file (required)")
-	scrapeCmd.Flags().StringVarP(&accessToken, "token", "t", "", "GitLab access token (optional, can also be set via GITLAB_ACCESS_TOKEN environment variable)")
-	scrapeCmd.Flags().StringVarP(&pushGatewayURL, "pushgateway", "p", "", "Prometheus Push Gateway URL (optional, can also be set via PUSHGATEWAY_URL environment variable)")
+	scrapeCmd.Flags().StringVarP(&configFile, "config", "c", "", "config file, or - to read it from stdin (required)")
+	scrapeCmd.Flags().StringVarP(&accessToken, "token", "t", "", "GitLab access token (optional, can also be set via GITLAB_SCRAPER_ACCESS_TOKEN environment variable)")
+	scrapeCmd.Flags().StringVarP(&pushGatewayURL, "pushgateway", "p", "", "Prometheus Push Gateway URL (optional, can also be set via GITLAB_SCRAPER_PUSH_GATEWAY_URL environment variable)")
+	scrapeCmd.Flags().StringVarP(&gitlabURL, "gitlab-url", "u", "", "GitLab URL (optional, can also be set via GITLAB_SCRAPER_GITLAB_URL environment variable, defaults to https://gitlab.com)")
+	scrapeCmd.Flags().StringVar(&pushJobPrefix, "push-job-prefix", "", "Prefix prepended to the Push Gateway job name")
+	scrapeCmd.Flags().StringVar(&pushJobSuffix, "push-job-suffix", "", "Suffix appended to the Push Gateway job name, e.g. _v2")
+	scrapeCmd.Flags().BoolVar(&skipCloneSize, "skip-clone-size", false, "Skip the clone size metric, which makes GitLab build a repository archive for every configured project")
+	scrapeCmd.Flags().BoolVar(&skipSSLVerify, "skip-ssl-verify", false, "Skip TLS certificate verification for the GitLab API")
+	scrapeCmd.Flags().BoolVar(&pushGatewaySkipVerify, "push-gateway-skip-verify", false, "Skip TLS certificate verification for the Prometheus Push Gateway")
+	scrapeCmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of groups scraped in parallel, in the order set by --scrape-order")
+	scrapeCmd.Flags().Float64SliceVar(&histogramBuckets, "histogram-buckets", defaultHistogramBuckets, "Comma-separated upper bounds in seconds of the buckets of duration histograms")
+	scrapeCmd.Flags().StringSliceVar(&metricsFilter, "metrics-filter", nil, "Comma-separated metric name globs, metrics producing none of the matching names are not collected")
 	scrapeCmd.MarkFlagRequired("config")
+
+	// Flags take precedence over environment variables, which take
+	// precedence over the config file.
+	viper.BindPFlag("access_token", scrapeCmd.Flags().Lookup("token"))
+	viper.BindPFlag("push_gateway_url", scrapeCmd.Flags().Lookup("pushgateway"))
+	viper.BindPFlag("gitlab_url", scrapeCmd.Flags().Lookup("gitlab-url"))
 }
 
-func getRequiredValue(key, envVar, errMsg string) string {
-	viper.BindEnv(key, envVar)
+func validateHistogramBuckets() error {
+	if len(histogramBuckets) == 0 {
+		return errors.New("--histogram-buckets must not be empty")
+	}
+	for i := 1; i < len(histogramBuckets); i++ {
+		if histogramBuckets[i] <= histogramBuckets[i-1] {
+			return fmt.Errorf("--histogram-buckets must be strictly increasing, but %g follows %g", histogramBuckets[i], histogramBuckets[i-1])
+		}
+	}
+	return nil
+}
+
+func getRequiredValue(key, errMsg string) string {
 	value := viper.GetString(key)
 	if value == "" {
-		fmt.Println(errMsg)
-		os.Exit(1)
+		fatalf("%s", errMsg)
 	}
 	return value
 }
 
-func scrape(config *Config, accessToken string, pushGatewayURL string) {
-	git, err := gitlab.NewClient(accessToken)
+// applyConnectionSettings resolves the connection settings of config, for
+// which flags and environment variables take precedence over the file.
+func applyConnectionSettings(config *Config) {
+	config.PushGatewayURL = os.ExpandEnv(viper.GetString("push_gateway_url"))
+	config.GitLabURL = os.ExpandEnv(viper.GetString("gitlab_url"))
+	config.JobName = pushJobPrefix + viper.GetString("job_name") + pushJobSuffix
+}
+
+// scrape collects and pushes all configured metrics once and returns them.
+// A group, project or other target that fails does not stop the scrape, the
+// metrics of the others are still pushed. The returned error reports every
+// failure, including a failed push.
+func scrape(config *Config, accessToken string) (prometheus.Gatherer, error) {
+	git, err := newGitLabClient(config, accessToken)
 	if err != nil {
-		fmt.Printf("Failed to create client: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 
-	pusher := push.New(pushGatewayURL, "gitlab_scrape")
+	loadStateIfEnabled(config)
+	resetSubgroupCache()
+	resetGroupProjectCache()
+	excludePatterns = config.excludePatterns
+
+	registry := prometheus.NewRegistry()
+	grouping := pushGrouping(config)
+	gatherer := newTransformGatherer(registry, config)
+	pusher := newPusher(config, config.JobName, grouping).Gatherer(gatherer)
+
+	var mu sync.Mutex
+	addCollectors := func(collectors []prometheus.Collector) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, collector := range collectors {
+			if err := registry.Register(collector); err != nil {
+				fatalf("Failed to register metric: %v", err)
+			}
+		}
+	}
+
+	report := &StatusReport{}
+	groupIDs, projectIDs := redactedIDs(config)
+
+	ctx := context.Background()
+	if config.GlobalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.GlobalTimeout)
+		defer cancel()
+	}
+
+	skipped := scrapeGroups(ctx, concurrency, config.Groups, func(group GroupConfig) {
+		if config.PanicRecovery {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					addCollectors([]prometheus.Collector{groupPanicked(config, group, recovered, report)})
+				}
+			}()
+		}
+		groupCtx := ctx
+		if group.Timeout > 0 {
+			var cancel context.CancelFunc
+			groupCtx, cancel = context.WithTimeout(ctx, group.Timeout)
+			defer cancel()
+		}
+
+		err := scrapeGroup(groupCtx, git, config, group, addCollectors)
+		if err != nil && groupCtx.Err() == nil {
+			fmt.Printf("Failed to scrape group %s, pushing the metrics collected so far: %v\n", groupIDs[group.ID], err)
+			report.Add(group.ID, GroupFailed, err)
+			return
+		}
+		if err != nil {
+			fmt.Printf("Timed out scraping group %s, pushing the metrics collected so far\n", groupIDs[group.ID])
+			report.Add(group.ID, GroupPartial, err)
+			return
+		}
+		report.Add(group.ID, GroupScraped, nil)
+	})
+	for _, group := range skipped {
+		fmt.Printf("Group %s was not scraped\n", groupIDs[group.ID])
+		report.Add(group.ID, GroupSkipped, nil)
+		labels := mergeLabels(config.DefaultLabels, group.ExtraLabels, prometheus.Labels{"group_id": group.ID})
+		addCollectors([]prometheus.Collector{newGauge("gitlab_scrape_group_not_scraped", "Set when the GitLab group was skipped because the scrape ran out of time", labels, 1)})
+	}
+
+	for _, project := range config.Projects {
+		labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"project_id": project.ID})
+		collectors, err := collectMetrics(ctx, projectMetrics, git, project, labels, config.DisableMetrics)
+		addCollectors(collectors)
+		if err != nil && ctx.Err() == nil {
+			report.Fail(fmt.Errorf("failed to scrape project %s: %w", projectIDs[project.ID], err))
+			continue
+		}
+		if err != nil {
+			fmt.Printf("Timed out scraping project %s, pushing the metrics collected so far\n", projectIDs[project.ID])
+			break
+		}
+	}
 
-	for _, group := range config.Groups {
-		if group.ProjectCount != nil {
-			projectCount := getProjectCount(git, group)
-			fmt.Printf("Project count in group %s: %d\n", group.ID, projectCount)
+	if (config.TrackProjectTransfers || config.TrackProjectArchival) && ctx.Err() == nil {
+		collectors, err := detectProjectEvents(ctx, git, config)
+		addCollectors(collectors)
+		if err != nil && ctx.Err() == nil {
+			report.Fail(fmt.Errorf("failed to detect project changes: %w", err))
+		}
+	}
 
-			labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"group_id": group.ID})
+	if config.ApplicationStats != nil && ctx.Err() == nil {
+		collectors, err := collectApplicationStats(ctx, git, config.DefaultLabels)
+		addCollectors(collectors)
+		if err != nil && ctx.Err() == nil {
+			report.Fail(fmt.Errorf("failed to scrape application statistics: %w", err))
+		}
+	}
 
-			projectCountGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-				Name:        "gitlab_group_project_count",
-				Help:        "Number of projects in the GitLab group",
-				ConstLabels: labels,
-			})
-			projectCountGauge.Set(float64(projectCount))
+	for _, namespace := range config.Namespaces {
+		if ctx.Err() != nil {
+			break
+		}
+		labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"namespace_id": namespace.ID})
+		collectors, err := collectNamespaceStats(ctx, git, namespace, labels)
+		addCollectors(collectors)
+		if err != nil && ctx.Err() == nil {
+			report.Fail(fmt.Errorf("failed to scrape namespace %s: %w", namespace.ID, err))
+		}
+	}
 
-			pusher.Collector(projectCountGauge)
+	if config.GroupProjectLanguageMatrix != nil && ctx.Err() == nil {
+		collectors, err := collectLanguageMatrix(ctx, git, config)
+		addCollectors(collectors)
+		if err != nil && ctx.Err() == nil {
+			report.Fail(fmt.Errorf("failed to scrape the language matrix: %w", err))
 		}
+	}
 
-		if group.MemberCount != nil {
-			groupMembersCount := getGroupMembersCount(git, group)
-			fmt.Printf("Group members count in group %s: %d\n", group.ID, groupMembersCount)
+	if len(config.GlobalExcludePatterns) > 0 {
+		addCollectors([]prometheus.Collector{projectsExcludedCounter(config.DefaultLabels)})
+	}
 
-			labels := mergeLabels(config.DefaultLabels, prometheus.Labels{"group_id": group.ID})
+	if staleness != nil {
+		families, err := registry.Gather()
+		if err != nil {
+			return gatherer, fmt.Errorf("failed to gather metrics: %w", err)
+		}
+		addCollectors(staleness.update(families, time.Now()))
+	}
 
-			groupMembersCountGauge := prometheus.NewGauge(prometheus.GaugeOpts{
-				Name:        "gitlab_group_members_count",
-				Help:        "Number of members in the GitLab group",
-				ConstLabels: labels,
-			})
-			groupMembersCountGauge.Set(float64(groupMembersCount))
+	if !beginPush() {
+		fmt.Println("Shutting down, skipping the push")
+		return gatherer, nil
+	}
+	err = pusher.Push()
+	endPush()
+	if err != nil {
+		report.Fail(fmt.Errorf("failed to push metrics to Push Gateway: %w", err))
+	} else if state != nil {
+		// The state only records what was pushed, so it is kept as is when
+		// the push failed.
+		values, err := gatherValues(registry)
+		if err != nil {
+			return gatherer, fmt.Errorf("failed to gather metrics: %w", err)
+		}
+		state.LastPush = pushState(config.JobName, grouping, values)
+		if err := state.save(stateFile); err != nil {
+			report.Fail(fmt.Errorf("failed to save state: %w", err))
+		}
+	}
+	if err := writeOutput(gatherer); err != nil {
+		report.Fail(fmt.Errorf("failed to write %s output: %w", outputFormat, err))
+	}
+	if outputFile != "" {
+		if err := writeMetricsFile(gatherer, outputFile, compactOutput); err != nil {
+			report.Fail(fmt.Errorf("failed to write metrics to %s: %w", outputFile, err))
+		}
+	}
+	if snapshotSave != "" {
+		if err := writeSnapshot(registry, config, snapshotSave, time.Now()); err != nil {
+			report.Fail(fmt.Errorf("failed to save snapshot to %s: %w", snapshotSave, err))
+		}
+	}
 
-			pusher.Collector(groupMembersCountGauge)
+	report.Redact(config)
+	report.Print()
+	if ctx.Err() != nil {
+		warnf("the scrape did not finish within the global timeout of %s", config.GlobalTimeout)
+	}
+	return gatherer, report.Err()
+}
+
+// scrapeGroup collects all metrics of a group and hands them to collect,
+// including the ones collected before an error occurred.
+func scrapeGroup(ctx context.Context, git *gitlab.Client, config *Config, group GroupConfig, collect func([]prometheus.Collector)) error {
+	labels := mergeLabels(config.DefaultLabels, group.ExtraLabels, prometheus.Labels{"group_id": group.ID})
+	if len(group.LabelsFromGroupFields) > 0 {
+		fieldLabels, err := getGroupFieldLabels(ctx, git, group)
+		if err != nil {
+			return err
 		}
+		labels = mergeLabels(labels, fieldLabels)
 	}
 
-	if err := pusher.Push(); err != nil {
-		fmt.Printf("Failed to push metrics to Push Gateway: %v\n", err)
-		os.Exit(1)
+	definitions := groupMetrics
+	var retries int
+	if retryOnEmpty > 0 {
+		definitions = retryEmptyValues(definitions, &retries)
 	}
+
+	collectors, err := collectMetrics(ctx, definitions, git, group, labels, config.DisableMetrics)
+	if retries > 0 {
+		collectors = append(collectors, emptyValueRetriesCounter(labels, retries))
+	}
+	collect(collectors)
+	return err
 }
 
-func getProjectCount(git *gitlab.Client, group GroupConfig) int {
+func getProjectCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
 	includeSubGroups := false
 	if group.ProjectCount.IncludeSubGroups != nil {
 		includeSubGroups = *group.ProjectCount.IncludeSubGroups
 	}
 
-	_, resp, err := git.Groups.ListGroupProjects(group.ID, &gitlab.ListGroupProjectsOptions{
-		IncludeSubGroups: gitlab.Ptr(includeSubGroups),
-		ListOptions: gitlab.ListOptions{
-			Page:    1,
-			PerPage: 1,
-		},
-		Simple: gitlab.Ptr(true),
+	return sumOverSubgroups(ctx, git, group, includeSubGroups, func(id string, includeSubGroups bool) (int, error) {
+		_, resp, err := git.Groups.ListGroupProjects(id, &gitlab.ListGroupProjectsOptions{
+			IncludeSubGroups: gitlab.Ptr(includeSubGroups),
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 1,
+			},
+			Simple: gitlab.Ptr(true),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list projects for group %s: %w", id, err)
+		}
+		return resp.TotalItems, nil
 	})
-	if err != nil {
-		fmt.Printf("Failed to list projects for group %s: %v\n", group.ID, err)
-		os.Exit(1)
-	}
+}
 
-	return resp.TotalItems
+func getGroupMembersCount(ctx context.Context, git *gitlab.Client, group GroupConfig) (int, error) {
+	return sumOverSubgroups(ctx, git, group, false, func(id string, _ bool) (int, error) {
+		options := &gitlab.ListGroupMembersOptions{
+			ListOptions: gitlab.ListOptions{
+				Page:    1,
+				PerPage: 1,
+			},
+		}
+
+		_, resp, err := git.Groups.ListGroupMembers(id, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to list members for group %s: %w", id, err)
+		}
+		return resp.TotalItems, nil
+	})
 }
 
-func getGroupMembersCount(git *gitlab.Client, group GroupConfig) int {
-	options := &gitlab.ListGroupMembersOptions{
-		ListOptions: gitlab.ListOptions{
-			Page:    1,
-			PerPage: 1,
-		},
+// pushGrouping resolves the grouping keys of push_gateway_grouping_from_env.
+func pushGrouping(config *Config) map[string]string {
+	grouping := map[string]string{}
+	for _, key := range slices.Sorted(maps.Keys(config.PushGatewayGroupingFromEnv)) {
+		envVar := config.PushGatewayGroupingFromEnv[key]
+		value := os.Getenv(envVar)
+		if value == "" {
+			warnf("environment variable %s is not set, skipping Push Gateway grouping key %s", envVar, key)
+			continue
+		}
+		grouping[key] = value
 	}
+	return grouping
+}
 
-	_, resp, err := git.Groups.ListGroupMembers(group.ID, options)
-	if err != nil {
-		fmt.Printf("Failed to list members for group %s: %v\n", group.ID, err)
-		os.Exit(1)
+func newPusher(config *Config, job string, grouping map[string]string) *push.Pusher {
+	pusher := push.New(config.PushGatewayURL, job)
+	if pushGatewaySkipVerify {
+		warnf("TLS certificate verification for the Push Gateway is disabled")
+		pusher.Client(insecureHTTPClient())
+	}
+	for _, key := range slices.Sorted(maps.Keys(grouping)) {
+		pusher.Grouping(key, grouping[key])
 	}
-	return resp.TotalItems
+	return pusher
+}
+
+func newGauge(name, help string, labels prometheus.Labels, value float64) prometheus.Gauge {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+	})
+	gauge.Set(value)
+	return gauge
+}
+
+// newDurationSnapshotHistogram creates a histogram of durations in seconds
+// with the --histogram-buckets boundaries. Like the gauges it is created
+// anew every scrape and only holds the durations observed in that scrape,
+// so its buckets, _count and _sum describe a snapshot and do not grow
+// monotonically; compare them across scrapes rather than applying rate().
+func newDurationSnapshotHistogram(name, help string, labels prometheus.Labels) prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:        name,
+		Help:        help,
+		ConstLabels: labels,
+		Buckets:     histogramBuckets,
+	})
 }
 
 func mergeLabels(labelSets ...prometheus.Labels) prometheus.Labels {
@@ -0,0 +1,138 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type SecurityPolicyCount struct {
+	ScanExecution        int
+	MergeRequestApproval int
+	// Available is false on instances without security policies, which are
+	// a GitLab Ultimate feature.
+	Available bool
+}
+
+const securityPoliciesQuery = `query($fullPath: ID!) {
+  project(fullPath: $fullPath) {
+    scanExecutionPolicies { nodes { name } }
+    approvalPolicies { nodes { name } }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type securityPoliciesResponse struct {
+	Project *struct {
+		ScanExecutionPolicies *struct {
+			Nodes []struct{ Name string } `json:"nodes"`
+		} `json:"scanExecutionPolicies"`
+		ApprovalPolicies *struct {
+			Nodes []struct{ Name string } `json:"nodes"`
+		} `json:"approvalPolicies"`
+	} `json:"project"`
+}
+
+// getSecurityPolicyCount counts the scan execution and merge request approval
+// policies that apply to the project. The policy configuration is only
+// exposed through GraphQL, which needs the full path of the project.
+func getSecurityPolicyCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (SecurityPolicyCount, error) {
+	fullPath, err := projectFullPath(ctx, git, project.ID)
+	if err != nil {
+		return SecurityPolicyCount{}, err
+	}
+
+	var policies securityPoliciesResponse
+	ok, err := doGraphQL(ctx, git, securityPoliciesQuery, map[string]any{"fullPath": fullPath}, &policies)
+	if err != nil {
+		return SecurityPolicyCount{}, fmt.Errorf("failed to get security policies for project %s: %w", project.ID, err)
+	}
+
+	// Instances without the feature reject the unknown fields of the query.
+	result := policies.Project
+	if !ok || result == nil || result.ScanExecutionPolicies == nil || result.ApprovalPolicies == nil {
+		return SecurityPolicyCount{}, nil
+	}
+
+	return SecurityPolicyCount{
+		ScanExecution:        len(result.ScanExecutionPolicies.Nodes),
+		MergeRequestApproval: len(result.ApprovalPolicies.Nodes),
+		Available:            true,
+	}, nil
+}
+
+// projectFullPath returns the full path GraphQL identifies projects by,
+// looking it up if projectID is numeric.
+func projectFullPath(ctx context.Context, git *gitlab.Client, projectID string) (string, error) {
+	if strings.Contains(projectID, "/") {
+		return projectID, nil
+	}
+	p, _, err := git.Projects.GetProject(projectID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to get project %s: %w", projectID, err)
+	}
+	return p.PathWithNamespace, nil
+}
+
+// doGraphQL runs the GraphQL query with the variables and decodes the data
+// of the response into out. ok is false, without an error, when GraphQL is
+// not available to the token or the response has errors, which instances
+// without a feature report for the unknown fields of a query. Data that
+// came with errors is still decoded. The errors are printed.
+func doGraphQL(ctx context.Context, git *gitlab.Client, query string, variables map[string]any, out any) (ok bool, err error) {
+	body := graphQLRequest{Query: query, Variables: variables}
+	req, err := git.NewRequest(http.MethodPost, "", body, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return false, fmt.Errorf("failed to create GraphQL request: %w", err)
+	}
+	req.URL = graphQLURL(git.BaseURL())
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	resp, err := git.Do(req, &response)
+	if isFeatureUnavailable(resp, err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	description := "GraphQL query"
+	if fullPath, ok := variables["fullPath"]; ok {
+		description += fmt.Sprintf(" for %v", fullPath)
+	}
+	for _, graphQLErr := range response.Errors {
+		fmt.Printf("%s failed: %s\n", description, graphQLErr.Message)
+	}
+	if len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, out); err != nil {
+			return false, fmt.Errorf("failed to decode GraphQL response: %w", err)
+		}
+	}
+	return len(response.Errors) == 0, nil
+}
+
+func graphQLURL(baseURL *url.URL) *url.URL {
+	u := *baseURL
+	root := strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/api/v4")
+	u.Path = root + "/api/graphql"
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u
+}
@@ -0,0 +1,191 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	listenAddress         string
+	writePrometheusConfig string
+)
+
+const defaultPrometheusScrapeInterval = time.Minute
+
+func init() {
+	scrapeCmd.Flags().StringVar(&listenAddress, "listen-address", "", "Serve the metrics of the latest scrape on /metrics at this address, e.g. :9100, or on a Unix socket such as unix:///run/scraper.sock (requires --interval, overrides listen_addr)")
+	scrapeCmd.Flags().StringVar(&writePrometheusConfig, "write-prometheus-config", "", "Write a Prometheus scrape job for the --listen-address server to this file, or - for stdout, and exit")
+}
+
+// latestGatherer serves the metrics of the most recent scrape.
+type latestGatherer struct {
+	gatherer atomic.Pointer[prometheus.Gatherer]
+}
+
+func (l *latestGatherer) Store(g prometheus.Gatherer) { l.gatherer.Store(&g) }
+
+func (l *latestGatherer) Gather() ([]*dto.MetricFamily, error) {
+	g := l.gatherer.Load()
+	if g == nil {
+		return nil, nil
+	}
+	return (*g).Gather()
+}
+
+// unixSocketMode lets the group of the scraper connect to its socket, e.g. a
+// sidecar that forwards the metrics.
+const unixSocketMode = 0o660
+
+// unixSocketPath returns the socket path of a listen address that starts
+// with / or unix://.
+func unixSocketPath(address string) (string, bool) {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		return path, true
+	}
+	return address, strings.HasPrefix(address, "/")
+}
+
+func listen(address string) (net.Listener, error) {
+	path, ok := unixSocketPath(address)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	// A socket left behind by a previous run would fail the listen.
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// startServer serves the metrics on address, a TCP address or a Unix socket
+// path. The returned function removes the socket file.
+func startServer(address string, gatherer prometheus.Gatherer, hub *metricsHub) func() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler(gatherer))
+	if hub != nil {
+		mux.Handle("/ws/metrics", hub)
+	}
+
+	listener, err := listen(address)
+	if err != nil {
+		fatalf("Failed to listen on %s: %v", address, err)
+	}
+
+	fmt.Printf("Serving metrics on %s/metrics\n", address)
+	go func() {
+		if err := http.Serve(listener, mux); err != nil && !errors.Is(err, net.ErrClosed) {
+			fatalf("Failed to serve metrics: %v", err)
+		}
+	}()
+	return func() {
+		listener.Close()
+		if path, ok := unixSocketPath(address); ok {
+			os.Remove(path)
+		}
+	}
+}
+
+// metricsHandler serves the metrics of gatherer in the export format.
+func metricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to gather metrics: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		format := exportFormat()
+		w.Header().Set("Content-Type", string(format))
+		if err := encodeMetrics(w, format, families); err != nil {
+			fmt.Printf("Failed to write metrics response: %v\n", err)
+		}
+	})
+}
+
+// prometheusScrapeTarget turns a listen address such as :9100 into an
+// address Prometheus can scrape.
+func prometheusScrapeTarget(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// writePrometheusScrapeConfig writes a prometheus.yml scrape job for the
+// embedded server that only keeps the metrics of the scraper.
+func writePrometheusScrapeConfig(path string, config *Config) error {
+	if listenAddress == "" {
+		return errors.New("--write-prometheus-config requires --listen-address")
+	}
+	if _, ok := unixSocketPath(listenAddress); ok {
+		return errors.New("--write-prometheus-config requires a TCP --listen-address, Prometheus cannot scrape Unix sockets")
+	}
+	scrapeInterval := interval
+	if scrapeInterval == 0 {
+		scrapeInterval = defaultPrometheusScrapeInterval
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create Prometheus config file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	_, err := fmt.Fprintf(w, `scrape_configs:
+  - job_name: %q
+    scrape_interval: %s
+    static_configs:
+      - targets: [%q]
+    metric_relabel_configs:
+      - source_labels: [__name__]
+        regex: "gitlab_.*"
+        action: keep
+`, config.JobName, prometheusDuration(scrapeInterval), prometheusScrapeTarget(listenAddress))
+	if err != nil {
+		return fmt.Errorf("failed to write Prometheus config: %w", err)
+	}
+	return nil
+}
+
+// prometheusDuration formats d in the duration syntax of Prometheus, which
+// does not accept the combined units of time.Duration such as 1m30s.
+func prometheusDuration(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d/time.Millisecond)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServeOnUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "m.sock")
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newGauge("gitlab_group_member_count", "Number of members", prometheus.Labels{"group_id": "1"}, 3))
+
+	shutdown := startServer("unix://"+path, registry, nil)
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	resp, err := client.Get("http://unix/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics returned %s", resp.Status)
+	}
+	if !strings.Contains(string(body), `gitlab_group_member_count{group_id="1"} 3`) {
+		t.Errorf("GET /metrics did not return the gauge:\n%s", body)
+	}
+
+	shutdown()
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("socket %s was not removed on shutdown: %v", path, err)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	snapshotSave   string
+	snapshotReplay string
+)
+
+func init() {
+	scrapeCmd.Flags().StringVar(&snapshotSave, "snapshot-save", "", "Also save the scraped metrics to this JSON file for --snapshot-replay")
+	scrapeCmd.Flags().StringVar(&snapshotReplay, "snapshot-replay", "", "Push the metrics of a --snapshot-save file instead of scraping GitLab")
+}
+
+// Snapshot is the file format of --snapshot-save. It records which scraper
+// and config produced the metrics, so that a replay can be traced back.
+type Snapshot struct {
+	ScraperVersion string           `json:"scraper_version"`
+	ConfigHash     string           `json:"config_hash"`
+	CreatedAt      time.Time        `json:"created_at"`
+	Metrics        []SnapshotMetric `json:"metrics"`
+}
+
+type SnapshotMetric struct {
+	Name      string            `json:"name"`
+	Help      string            `json:"help,omitempty"`
+	Type      string            `json:"type"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+func scraperVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// configHash identifies the config the metrics were scraped with, without
+// storing it in the snapshot.
+func configHash(config *Config) (string, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// writeSnapshot saves the gauges and counters of g. Histograms cannot be
+// replayed as single values and are left out.
+func writeSnapshot(g prometheus.Gatherer, config *Config, path string, now time.Time) error {
+	families, err := g.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %w", err)
+	}
+	hash, err := configHash(config)
+	if err != nil {
+		return fmt.Errorf("failed to hash config: %w", err)
+	}
+
+	snapshot := Snapshot{ScraperVersion: scraperVersion(), ConfigHash: hash, CreatedAt: now, Metrics: []SnapshotMetric{}}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			entry := SnapshotMetric{Name: family.GetName(), Help: family.GetHelp(), Timestamp: now}
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				entry.Type, entry.Value = "gauge", metric.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				entry.Type, entry.Value = "counter", metric.GetCounter().GetValue()
+			default:
+				continue
+			}
+			if len(metric.GetLabel()) > 0 {
+				entry.Labels = map[string]string{}
+				for _, label := range metric.GetLabel() {
+					entry.Labels[label.GetName()] = label.GetValue()
+				}
+			}
+			snapshot.Metrics = append(snapshot.Metrics, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// replaySnapshot pushes the metrics of a snapshot file as they were saved.
+func replaySnapshot(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	fmt.Printf("Replaying %d metrics scraped at %s by scraper version %s with config %s\n",
+		len(snapshot.Metrics), snapshot.CreatedAt.Format(time.RFC3339), snapshot.ScraperVersion, snapshot.ConfigHash)
+
+	registry := prometheus.NewRegistry()
+	for _, metric := range snapshot.Metrics {
+		var collector prometheus.Collector
+		switch metric.Type {
+		case "gauge":
+			collector = newGauge(metric.Name, metric.Help, metric.Labels, metric.Value)
+		case "counter":
+			counter := prometheus.NewCounter(prometheus.CounterOpts{Name: metric.Name, Help: metric.Help, ConstLabels: metric.Labels})
+			counter.Add(metric.Value)
+			collector = counter
+		default:
+			return fmt.Errorf("metric %s in snapshot %s has unknown type %q", metric.Name, path, metric.Type)
+		}
+		if err := registry.Register(collector); err != nil {
+			return fmt.Errorf("failed to register metric %s: %w", metric.Name, err)
+		}
+	}
+
+	if err := newPusher(config, config.JobName, pushGrouping(config)).Gatherer(newTransformGatherer(registry, config)).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to Push Gateway: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// stalenessTracker remembers the series collected by previous scrapes in
+// daemon mode. Every push replaces the metrics of the job, so series that a
+// scrape failed to collect are pushed again with their last known value and
+// type, until they have not been collected for longer than the TTL. Then
+// they are forgotten and disappear with the next push.
+type stalenessTracker struct {
+	ttl time.Duration
+
+	mu          sync.Mutex
+	lastUpdated map[string]time.Time
+	series      map[string]staleSeries
+}
+
+// staleSeries is a series as it was last collected.
+type staleSeries struct {
+	name, help string
+	kind       dto.MetricType
+	metric     *dto.Metric
+}
+
+// staleness is set by the daemon when metric_ttl is configured.
+var staleness *stalenessTracker
+
+func newStalenessTracker(ttl time.Duration) *stalenessTracker {
+	return &stalenessTracker{
+		ttl:         ttl,
+		lastUpdated: map[string]time.Time{},
+		series:      map[string]staleSeries{},
+	}
+}
+
+func seriesKey(name string, labels []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, label := range labels {
+		b.WriteString("\xff" + label.GetName() + "=" + label.GetValue())
+	}
+	return b.String()
+}
+
+// update records the metric families collected at now and returns
+// collectors for the previously collected series that are missing from
+// them and have not expired yet.
+func (t *stalenessTracker) update(families []*dto.MetricFamily, now time.Time) []prometheus.Collector {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	collected := map[string]bool{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := seriesKey(family.GetName(), metric.GetLabel())
+			collected[key] = true
+			t.lastUpdated[key] = now
+			t.series[key] = staleSeries{name: family.GetName(), help: family.GetHelp(), kind: family.GetType(), metric: metric}
+		}
+	}
+
+	var collectors []prometheus.Collector
+	for key, series := range t.series {
+		if collected[key] {
+			continue
+		}
+		if now.Sub(t.lastUpdated[key]) > t.ttl {
+			delete(t.series, key)
+			delete(t.lastUpdated, key)
+			continue
+		}
+		if metric := series.constMetric(); metric != nil {
+			collectors = append(collectors, metricCollector{metric})
+		}
+	}
+	return collectors
+}
+
+// constMetric recreates the series with its original type, or returns nil
+// for types that cannot be recreated.
+func (s staleSeries) constMetric() prometheus.Metric {
+	labels := prometheus.Labels{}
+	for _, label := range s.metric.GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	desc := prometheus.NewDesc(s.name, s.help, nil, labels)
+
+	switch s.kind {
+	case dto.MetricType_COUNTER:
+		return prometheus.MustNewConstMetric(desc, prometheus.CounterValue, s.metric.GetCounter().GetValue())
+	case dto.MetricType_GAUGE:
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, s.metric.GetGauge().GetValue())
+	case dto.MetricType_UNTYPED:
+		return prometheus.MustNewConstMetric(desc, prometheus.UntypedValue, s.metric.GetUntyped().GetValue())
+	case dto.MetricType_HISTOGRAM:
+		histogram := s.metric.GetHistogram()
+		buckets := map[float64]uint64{}
+		for _, bucket := range histogram.GetBucket() {
+			buckets[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+		}
+		return prometheus.MustNewConstHistogram(desc, histogram.GetSampleCount(), histogram.GetSampleSum(), buckets)
+	case dto.MetricType_SUMMARY:
+		summary := s.metric.GetSummary()
+		quantiles := map[float64]float64{}
+		for _, quantile := range summary.GetQuantile() {
+			quantiles[quantile.GetQuantile()] = quantile.GetValue()
+		}
+		return prometheus.MustNewConstSummary(desc, summary.GetSampleCount(), summary.GetSampleSum(), quantiles)
+	}
+	return nil
+}
+
+// metricCollector collects a single metric.
+type metricCollector struct {
+	metric prometheus.Metric
+}
+
+func (c metricCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.metric.Desc() }
+func (c metricCollector) Collect(ch chan<- prometheus.Metric) { ch <- c.metric }
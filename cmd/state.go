@@ -0,0 +1,294 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+var stateFile string
+
+func init() {
+	scrapeCmd.Flags().StringVar(&stateFile, "state-file", "gitlab-scraper-state.json", "File that keeps state between scrapes for the features that need it")
+}
+
+// State is persisted between scrapes in the state file. It is safe for
+// concurrent use by the scrape workers.
+type State struct {
+	LastPush *PushState `json:"last_push,omitempty"`
+	// ProjectCounts holds the project count history of every group with
+	// project_count_growth_rate.
+	ProjectCounts map[string][]CountSample `json:"project_counts,omitempty"`
+	// ProjectNamespaces holds the namespace of every configured project
+	// with track_project_transfers.
+	ProjectNamespaces map[string]string `json:"project_namespaces,omitempty"`
+	// ProjectArchived holds whether every configured project was archived,
+	// with track_project_archival.
+	ProjectArchived map[string]bool `json:"project_archived,omitempty"`
+	// MemberCounts holds the member count by role of every group with
+	// membership_change, and MemberChanges the changes counted so far.
+	MemberCounts  map[string]map[string]int           `json:"member_counts,omitempty"`
+	MemberChanges map[string]map[string]MemberChanges `json:"member_changes,omitempty"`
+	// GroupValues holds the sum of every metric of every group by metric
+	// name with --retry-on-empty.
+	GroupValues map[string]map[string]float64 `json:"group_values,omitempty"`
+	// CounterValues holds the last value of every series of the metrics
+	// forced to counters with force_metric_type, by name and labels.
+	CounterValues map[string]float64 `json:"counter_values,omitempty"`
+
+	mu sync.Mutex
+}
+
+type CountSample struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// MemberChanges are the members added and removed in every scrape so far.
+// As only counts are compared, a member replaced by another between two
+// scrapes goes unnoticed.
+type MemberChanges struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// countSampleSpacing thins out the count history, as daemon mode would
+// otherwise record a sample every scrape.
+const countSampleSpacing = time.Hour
+
+// PushState describes what the last successful push sent to the Push
+// Gateway.
+type PushState struct {
+	Job      string            `json:"job"`
+	Grouping map[string]string `json:"grouping,omitempty"`
+	// Groups maps the ID of every pushed group to its metric names.
+	Groups map[string][]string `json:"groups,omitempty"`
+}
+
+// state is loaded on startup when a feature needs it and nil otherwise.
+var state *State
+
+func stateEnabled(config *Config) bool {
+	return pushDeleteOnMismatch || retryOnEmpty > 0 || config.TrackProjectTransfers || config.TrackProjectArchival || slices.Contains(slices.Collect(maps.Values(config.ForceMetricType)), metricTypeCounter) || slices.ContainsFunc(config.Groups, func(group GroupConfig) bool {
+		return group.ProjectCountGrowthRate != nil || group.MembershipChange != nil
+	})
+}
+
+// loadStateIfEnabled loads the state file once a feature of config needs it.
+func loadStateIfEnabled(config *Config) {
+	if state != nil || !stateEnabled(config) {
+		return
+	}
+	var err error
+	if state, err = loadState(stateFile); err != nil {
+		fatalf("Failed to load state: %v", err)
+	}
+}
+
+// loadState reads the state file. A missing file is an empty state, since
+// there is nothing to remember before the first scrape.
+func loadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+// save writes the state through a temporary file, so that an interrupted
+// write does not leave a truncated state file behind.
+func (s *State) save(path string) error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// recordProjectCount adds count to the history of the group and returns its
+// change since the newest sample that is at least window old. ok is false
+// if the history does not reach back that far yet.
+func (s *State) recordProjectCount(groupID string, count int, now time.Time, window time.Duration) (growth int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ProjectCounts == nil {
+		s.ProjectCounts = map[string][]CountSample{}
+	}
+
+	samples := s.ProjectCounts[groupID]
+	cutoff := now.Add(-window)
+	baseline := -1
+	for i, sample := range samples {
+		if !sample.Time.After(cutoff) {
+			baseline = i
+		}
+	}
+	if baseline >= 0 {
+		growth, ok = count-samples[baseline].Count, true
+		// Older samples are no longer needed as a baseline.
+		samples = samples[baseline:]
+	}
+
+	if len(samples) == 0 || now.Sub(samples[len(samples)-1].Time) >= countSampleSpacing {
+		samples = append(samples, CountSample{Time: now, Count: count})
+	}
+	s.ProjectCounts[groupID] = samples
+	return growth, ok
+}
+
+// recordProjectNamespace stores the namespace of the project and returns the
+// one stored before. ok is false if the project had no namespace yet.
+func (s *State) recordProjectNamespace(projectID, namespace string) (previous string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ProjectNamespaces == nil {
+		s.ProjectNamespaces = map[string]string{}
+	}
+	previous, ok = s.ProjectNamespaces[projectID]
+	s.ProjectNamespaces[projectID] = namespace
+	return previous, ok
+}
+
+// recordProjectArchived stores whether the project is archived and returns
+// what was stored before. ok is false if nothing was stored yet.
+func (s *State) recordProjectArchived(projectID string, archived bool) (previous bool, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ProjectArchived == nil {
+		s.ProjectArchived = map[string]bool{}
+	}
+	previous, ok = s.ProjectArchived[projectID]
+	s.ProjectArchived[projectID] = archived
+	return previous, ok
+}
+
+// recordMemberCounts stores the member counts of the group by role and adds
+// the difference to the previous counts to the changes of the group, which
+// it returns. The first counts of a group are the baseline, and so are the
+// first counts after by_role was switched on or off, since the total and the
+// counts by role cannot be compared.
+func (s *State) recordMemberCounts(groupID string, counts map[string]int) map[string]MemberChanges {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.MemberCounts == nil {
+		s.MemberCounts = map[string]map[string]int{}
+	}
+	if s.MemberChanges == nil {
+		s.MemberChanges = map[string]map[string]MemberChanges{}
+	}
+
+	previous, seen := s.MemberCounts[groupID]
+	changes := s.MemberChanges[groupID]
+	_, total := counts[""]
+	if _, previousTotal := previous[""]; seen && total != previousTotal {
+		previous, seen, changes = nil, false, nil
+	}
+	if changes == nil {
+		changes = map[string]MemberChanges{}
+	}
+	roles := map[string]bool{}
+	for role := range counts {
+		roles[role] = true
+	}
+	for role := range previous {
+		roles[role] = true
+	}
+	for role := range roles {
+		change := changes[role]
+		if seen {
+			if diff := counts[role] - previous[role]; diff > 0 {
+				change.Added += diff
+			} else {
+				change.Removed -= diff
+			}
+		}
+		changes[role] = change
+	}
+
+	s.MemberCounts[groupID] = counts
+	s.MemberChanges[groupID] = changes
+	return maps.Clone(changes)
+}
+
+// groupValues returns the metric values last recorded for the group.
+func (s *State) groupValues(groupID string) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.GroupValues[groupID])
+}
+
+// recordGroupValues stores the metric values of the group, keeping the
+// values of the metrics that are not part of values.
+func (s *State) recordGroupValues(groupID string, values map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.GroupValues == nil {
+		s.GroupValues = map[string]map[string]float64{}
+	}
+	if s.GroupValues[groupID] == nil {
+		s.GroupValues[groupID] = map[string]float64{}
+	}
+	maps.Copy(s.GroupValues[groupID], values)
+}
+
+// recordCounterValue stores the value of a counter series and returns the
+// value to report, which is the previous one if value is lower, since a
+// counter must not decrease. ok is false in that case.
+func (s *State) recordCounterValue(series string, value float64) (reported float64, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.CounterValues == nil {
+		s.CounterValues = map[string]float64{}
+	}
+	if previous, found := s.CounterValues[series]; found && value < previous {
+		return previous, false
+	}
+	s.CounterValues[series] = value
+	return value, true
+}
+
+const defaultGrowthRateWindowDays = 7
+
+func (c *GrowthRateConfig) windowDays() int {
+	if c.WindowDays <= 0 {
+		return defaultGrowthRateWindowDays
+	}
+	return c.WindowDays
+}
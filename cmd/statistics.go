@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type GroupStatistics struct {
+	RepositorySize   int64 `json:"repository_size"`
+	LFSObjectsSize   int64 `json:"lfs_objects_size"`
+	JobArtifactsSize int64 `json:"job_artifacts_size"`
+	PackagesSize     int64 `json:"packages_size"`
+}
+
+type groupProjectStatisticsOptions struct {
+	gitlab.ListOptions
+	IncludeSubGroups *bool `url:"include_subgroups,omitempty"`
+	Statistics       *bool `url:"statistics,omitempty"`
+}
+
+// getGroupStatistics reads the storage statistics of a group in a single
+// call. If the token lacks the statistics scope, it falls back to aggregating
+// the statistics of every project in the group and its subgroups.
+func getGroupStatistics(ctx context.Context, git *gitlab.Client, group GroupConfig) (GroupStatistics, error) {
+	u := fmt.Sprintf("groups/%s/statistics", gitlab.PathEscape(group.ID))
+	req, err := git.NewRequest(http.MethodGet, u, nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return GroupStatistics{}, fmt.Errorf("failed to create statistics request for group %s: %w", group.ID, err)
+	}
+
+	var stats GroupStatistics
+	resp, err := git.Do(req, &stats)
+	if isFeatureUnavailable(resp, err) {
+		fmt.Printf("Statistics endpoint is not available for group %s, aggregating project statistics\n", group.ID)
+		return aggregateProjectStatistics(ctx, git, group)
+	}
+	if err != nil {
+		return GroupStatistics{}, fmt.Errorf("failed to get statistics for group %s: %w", group.ID, err)
+	}
+	return stats, nil
+}
+
+func aggregateProjectStatistics(ctx context.Context, git *gitlab.Client, group GroupConfig) (GroupStatistics, error) {
+	var repository, lfsObjects, jobArtifacts, packages []float64
+	u := fmt.Sprintf("groups/%s/projects", gitlab.PathEscape(group.ID))
+	opt := &groupProjectStatisticsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+		IncludeSubGroups: gitlab.Ptr(true),
+		Statistics:       gitlab.Ptr(true),
+	}
+
+	for {
+		req, err := git.NewRequest(http.MethodGet, u, opt, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+		if err != nil {
+			return GroupStatistics{}, fmt.Errorf("failed to create project list request for group %s: %w", group.ID, err)
+		}
+
+		var projects []*gitlab.Project
+		resp, err := git.Do(req, &projects)
+		if err != nil {
+			return GroupStatistics{}, fmt.Errorf("failed to list project statistics for group %s: %w", group.ID, err)
+		}
+
+		for _, project := range projects {
+			if project.Statistics == nil || isExcludedProject(project) {
+				continue
+			}
+			repository = append(repository, float64(project.Statistics.RepositorySize))
+			lfsObjects = append(lfsObjects, float64(project.Statistics.LFSObjectsSize))
+			jobArtifacts = append(jobArtifacts, float64(project.Statistics.JobArtifactsSize))
+			packages = append(packages, float64(project.Statistics.PackagesSize))
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	fn := group.GroupStatistics.AggregationFunc
+	return GroupStatistics{
+		RepositorySize:   int64(aggregate(repository, fn)),
+		LFSObjectsSize:   int64(aggregate(lfsObjects, fn)),
+		JobArtifactsSize: int64(aggregate(jobArtifacts, fn)),
+		PackagesSize:     int64(aggregate(packages, fn)),
+	}, nil
+}
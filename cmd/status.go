@@ -0,0 +1,116 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type GroupStatus string
+
+const (
+	GroupScraped GroupStatus = "scraped"
+	// GroupPartial groups ran out of time; the metrics collected before the
+	// timeout were still pushed.
+	GroupPartial GroupStatus = "partial"
+	GroupSkipped GroupStatus = "skipped"
+	// GroupPanicked groups panicked with panic_recovery enabled; the metrics
+	// collected before the panic were still pushed.
+	GroupPanicked GroupStatus = "panicked"
+	// GroupFailed groups returned an error; the metrics collected before
+	// the error were still pushed.
+	GroupFailed GroupStatus = "failed"
+)
+
+type GroupStatusEntry struct {
+	ID     string
+	Status GroupStatus
+	Err    error
+}
+
+// StatusReport records the outcome of every group of a scrape and the
+// failures outside of groups, such as a failed push. It is safe for
+// concurrent use by the scrape workers.
+type StatusReport struct {
+	mu     sync.Mutex
+	Groups []GroupStatusEntry
+	Errors []error
+}
+
+func (r *StatusReport) Add(id string, status GroupStatus, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Groups = append(r.Groups, GroupStatusEntry{ID: id, Status: status, Err: err})
+}
+
+// Fail records a failure that does not belong to a group.
+func (r *StatusReport) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Errors = append(r.Errors, err)
+}
+
+// Err joins the errors of the failed and panicked groups and the other
+// failures, or returns nil if there are none. Partial and skipped groups ran
+// out of time and are not errors.
+func (r *StatusReport) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, entry := range r.Groups {
+		if entry.Status == GroupFailed || entry.Status == GroupPanicked {
+			errs = append(errs, fmt.Errorf("failed to scrape group %s: %w", entry.ID, entry.Err))
+		}
+	}
+	return errors.Join(append(errs, r.Errors...)...)
+}
+
+// Redact replaces the IDs of the groups with the form in which they may be
+// printed, so that Print and Err do not reveal the sensitive_fields of
+// config.
+func (r *StatusReport) Redact(config *Config) {
+	groupIDs, _ := redactedIDs(config)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, entry := range r.Groups {
+		if id, ok := groupIDs[entry.ID]; ok {
+			r.Groups[i].ID = id
+		}
+	}
+}
+
+func (r *StatusReport) Count(status GroupStatus) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, entry := range r.Groups {
+		if entry.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *StatusReport) Print() {
+	fmt.Printf("Scraped %d groups: %d complete, %d partial, %d skipped, %d failed, %d panicked\n",
+		len(r.Groups), r.Count(GroupScraped), r.Count(GroupPartial), r.Count(GroupSkipped), r.Count(GroupFailed), r.Count(GroupPanicked))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.Groups {
+		switch entry.Status {
+		case GroupPartial:
+			fmt.Printf("Group %s was partially scraped: %v\n", entry.ID, entry.Err)
+		case GroupPanicked:
+			fmt.Printf("Group %s failed with a %v\n", entry.ID, entry.Err)
+		case GroupFailed:
+			fmt.Printf("Group %s failed: %v\n", entry.ID, entry.Err)
+		}
+	}
+	for _, err := range r.Errors {
+		fmt.Printf("Scrape error: %v\n", err)
+	}
+}
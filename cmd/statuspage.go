@@ -0,0 +1,49 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+type StatusPage struct {
+	Enabled bool
+	// Available is false when the token may not read the status page
+	// setting of the project.
+	Available bool
+}
+
+// statusPageSetting is the part of the status page setting that is needed.
+// client-go does not implement the endpoint.
+type statusPageSetting struct {
+	Enabled bool `json:"enabled"`
+}
+
+// getStatusPageEnabled reads whether the project publishes a status page.
+// Projects that never configured one have no setting, which the API reports
+// as not found.
+func getStatusPageEnabled(ctx context.Context, git *gitlab.Client, project ProjectConfig) (StatusPage, error) {
+	u := fmt.Sprintf("projects/%s/status_page/setting", gitlab.PathEscape(project.ID))
+	req, err := git.NewRequest(http.MethodGet, u, nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return StatusPage{}, fmt.Errorf("failed to create status page request for project %s: %w", project.ID, err)
+	}
+
+	var setting statusPageSetting
+	resp, err := git.Do(req, &setting)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		return StatusPage{Available: true}, nil
+	}
+	if isFeatureUnavailable(resp, err) {
+		return StatusPage{}, nil
+	}
+	if err != nil {
+		return StatusPage{}, fmt.Errorf("failed to get status page setting for project %s: %w", project.ID, err)
+	}
+	return StatusPage{Enabled: setting.Enabled, Available: true}, nil
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// subgroupCache keeps the resolved subgroups_to_scrape of every group for
+// the duration of a scrape, so that the project and member counts of a group
+// share a single subgroup listing.
+var subgroupCache = struct {
+	sync.Mutex
+	ids map[string][]string
+}{ids: map[string][]string{}}
+
+func resetSubgroupCache() {
+	subgroupCache.Lock()
+	defer subgroupCache.Unlock()
+	subgroupCache.ids = map[string][]string{}
+}
+
+// getSubgroupsToScrape returns the IDs of the direct subgroups of group whose
+// full path ends with one of its subgroups_to_scrape.
+func getSubgroupsToScrape(ctx context.Context, git *gitlab.Client, group GroupConfig) ([]string, error) {
+	subgroupCache.Lock()
+	defer subgroupCache.Unlock()
+	if ids, ok := subgroupCache.ids[group.ID]; ok {
+		return ids, nil
+	}
+
+	found := map[string]bool{}
+	var ids []string
+	options := &gitlab.ListSubGroupsOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	for {
+		subgroups, resp, err := git.Groups.ListSubGroups(group.ID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list subgroups of group %s: %w", group.ID, err)
+		}
+
+		for _, subgroup := range subgroups {
+			for _, suffix := range group.SubgroupsToScrape {
+				if subgroup.FullPath == suffix || strings.HasSuffix(subgroup.FullPath, "/"+suffix) {
+					found[suffix] = true
+					ids = append(ids, strconv.Itoa(subgroup.ID))
+					break
+				}
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	for _, suffix := range group.SubgroupsToScrape {
+		if !found[suffix] {
+			warnf("subgroup %s of group %s was not found", suffix, group.ID)
+		}
+	}
+
+	subgroupCache.ids[group.ID] = ids
+	return ids, nil
+}
+
+// sumOverSubgroups adds up count for the group itself and, if the group
+// lists subgroups_to_scrape, for each of them. includeSubGroups tells count
+// whether to include nested groups, which is never done for a group that
+// restricts its subgroups, since that would count the unlisted ones.
+func sumOverSubgroups(ctx context.Context, git *gitlab.Client, group GroupConfig, includeSubGroups bool, count func(id string, includeSubGroups bool) (int, error)) (int, error) {
+	if len(group.SubgroupsToScrape) == 0 {
+		return count(group.ID, includeSubGroups)
+	}
+
+	subgroups, err := getSubgroupsToScrape(ctx, git, group)
+	if err != nil {
+		return 0, err
+	}
+
+	total, err := count(group.ID, false)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range subgroups {
+		n, err := count(id, includeSubGroups)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
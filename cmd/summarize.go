@@ -0,0 +1,182 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+)
+
+var (
+	summarizeInput   string
+	summarizeCompare string
+)
+
+// significantChange is the relative change to the baseline from which a
+// value is highlighted.
+const significantChange = 0.1
+
+// The color codes are zero width, but tabwriter counts them, so they all
+// have the same length to keep the columns aligned.
+const (
+	colorReset   = "\033[0m"
+	colorDefault = "\033[39m"
+	colorRed     = "\033[31m"
+	colorGreen   = "\033[32m"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+var summarizeCmd = &cobra.Command{
+	Use:   "summarize",
+	Short: "Print a summary table of a metrics file",
+	Long: `This command reads a metrics file in the Prometheus text format, as written by
+scrape --output, and prints every series with its labels and value, sorted by metric name.
+With --compare it also shows the trend from the values of a baseline file and highlights
+values that changed by more than 10%.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		current, err := readMetricsFile(summarizeInput)
+		if err != nil {
+			fatalf("Failed to read metrics: %v", err)
+		}
+		var baseline []metricValue
+		if summarizeCompare != "" {
+			if baseline, err = readMetricsFile(summarizeCompare); err != nil {
+				fatalf("Failed to read baseline metrics: %v", err)
+			}
+		}
+		summarize(os.Stdout, current, baseline, summarizeCompare != "", useColor(os.Stdout))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(summarizeCmd)
+	summarizeCmd.Flags().StringVarP(&summarizeInput, "input", "i", "", "metrics file in the Prometheus text format (required)")
+	summarizeCmd.Flags().StringVar(&summarizeCompare, "compare", "", "metrics file of an earlier scrape to compare the values against")
+	summarizeCmd.MarkFlagRequired("input")
+}
+
+// readMetricsFile parses a file in the Prometheus text format. Files written
+// with --compact-output have no TYPE lines and are read as untyped.
+func readMetricsFile(path string) ([]metricValue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metrics file: %w", err)
+	}
+	defer f.Close()
+
+	var families []*dto.MetricFamily
+	decoder := expfmt.NewDecoder(f, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for {
+		var family dto.MetricFamily
+		if err := decoder.Decode(&family); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse metrics file %s: %w", path, err)
+		}
+		families = append(families, &family)
+	}
+	return gatherValues(prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return families, nil }))
+}
+
+func summarize(out io.Writer, current, baseline []metricValue, compare, color bool) {
+	slices.SortFunc(current, func(a, b metricValue) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(formatLabels(a.Labels), formatLabels(b.Labels)))
+	})
+	previous := map[string]float64{}
+	for _, value := range baseline {
+		previous[value.Name+formatLabels(value.Labels)] = value.Value
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if compare {
+		fmt.Fprintln(w, "METRIC\tLABELS\tVALUE\tBASELINE\tTREND")
+	} else {
+		fmt.Fprintln(w, "METRIC\tLABELS\tVALUE")
+	}
+	for _, value := range current {
+		labels := formatLabels(value.Labels)
+		formatted := strconv.FormatFloat(value.Value, 'g', -1, 64)
+		if !compare {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", value.Name, labels, formatted)
+			continue
+		}
+
+		before, ok := previous[value.Name+labels]
+		if !ok {
+			if color {
+				formatted = colorDefault + formatted + colorReset
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t-\tnew\n", value.Name, labels, formatted)
+			continue
+		}
+		if color {
+			formatted = changeColor(before, value.Value) + formatted + colorReset
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", value.Name, labels, formatted,
+			strconv.FormatFloat(before, 'g', -1, 64), sparkline([]float64{before, value.Value}))
+	}
+	w.Flush()
+}
+
+func formatLabels(labels []*dto.LabelPair) string {
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = fmt.Sprintf("%s=%q", label.GetName(), label.GetValue())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// changeColor is green for a significant increase, red for a significant
+// decrease and the default color otherwise.
+func changeColor(before, after float64) string {
+	change := after - before
+	if before != 0 {
+		change /= math.Abs(before)
+	}
+	switch {
+	case change > significantChange:
+		return colorGreen
+	case change < -significantChange:
+		return colorRed
+	default:
+		return colorDefault
+	}
+}
+
+// sparkline draws values as bars scaled between their minimum and maximum.
+func sparkline(values []float64) string {
+	low, high := slices.Min(values), slices.Max(values)
+	var line strings.Builder
+	for _, value := range values {
+		block := len(sparkBlocks) / 2
+		if high > low {
+			block = int((value - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		line.WriteRune(sparkBlocks[block])
+	}
+	return line.String()
+}
+
+// useColor reports whether f is a terminal and NO_COLOR is not set.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+var configTemplate bool
+
+func init() {
+	const usage = "Render the config file as a Go template before reading it, e.g. group_id: \"{{ .Env.TEAM_GROUP_ID }}\""
+	scrapeCmd.Flags().BoolVar(&configTemplate, "config-template", false, usage)
+	scrapeExplainCmd.Flags().BoolVar(&configTemplate, "config-template", false, usage)
+	manifestValidateCmd.Flags().BoolVar(&configTemplate, "config-template", false, usage)
+}
+
+// configTemplateData is the data config templates are executed with.
+type configTemplateData struct {
+	// Env holds the environment variables of the scraper.
+	Env map[string]string
+}
+
+// renderConfigTemplate executes the config file named name as a text/template.
+// Referencing an unset environment variable as .Env.NAME is an error, while
+// {{ index .Env "NAME" }} renders it as an empty string. Errors name the
+// line, and where text/template knows it the column, in the config file.
+func renderConfigTemplate(name string, data []byte) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config template: %w", err)
+	}
+
+	env := map[string]string{}
+	for _, variable := range os.Environ() {
+		key, value, _ := strings.Cut(variable, "=")
+		env[key] = value
+	}
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, configTemplateData{Env: env}); err != nil {
+		return nil, fmt.Errorf("failed to render config template: %w", err)
+	}
+	return rendered.Bytes(), nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const terraformStatesQuery = `query($fullPath: ID!) {
+  project(fullPath: $fullPath) {
+    terraformStates { count }
+  }
+}`
+
+type terraformStatesResponse struct {
+	Project *struct {
+		TerraformStates *struct {
+			Count int `json:"count"`
+		} `json:"terraformStates"`
+	} `json:"project"`
+}
+
+// validateTerraformStateCounts rejects projects with both
+// infrastructure_target and terraform_state_count, which push the same
+// gitlab_project_terraform_state_count series.
+func validateTerraformStateCounts(projects []ProjectConfig) error {
+	for _, project := range projects {
+		if project.InfrastructureTarget != nil && project.TerraformStateCount != nil {
+			return fmt.Errorf("project %s has both infrastructure_target and terraform_state_count, which push the same metric, keep only terraform_state_count", project.ID)
+		}
+	}
+	return nil
+}
+
+type TerraformStateCount struct {
+	Count int
+	// Available is false when GitLab managed Terraform state is disabled for
+	// the project or the instance, as opposed to a project without states.
+	Available bool
+}
+
+// getTerraformStateCount counts the GitLab managed Terraform states of the
+// project. The REST API only serves states by name, so they are counted
+// through GraphQL.
+func getTerraformStateCount(ctx context.Context, git *gitlab.Client, project ProjectConfig) (TerraformStateCount, error) {
+	fullPath, err := projectFullPath(ctx, git, project.ID)
+	if err != nil {
+		return TerraformStateCount{}, err
+	}
+
+	var states terraformStatesResponse
+	ok, err := doGraphQL(ctx, git, terraformStatesQuery, map[string]any{"fullPath": fullPath}, &states)
+	if err != nil {
+		return TerraformStateCount{}, fmt.Errorf("failed to get Terraform states for project %s: %w", project.ID, err)
+	}
+
+	result := states.Project
+	if !ok || result == nil || result.TerraformStates == nil {
+		return TerraformStateCount{}, nil
+	}
+	return TerraformStateCount{Count: result.TerraformStates.Count, Available: true}, nil
+}
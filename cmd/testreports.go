@@ -0,0 +1,47 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// getTestReportStats reads the test report of the configured pipeline, or of
+// the latest pipeline on the default branch. A project without pipelines or
+// test reports yields an empty report.
+func getTestReportStats(ctx context.Context, git *gitlab.Client, project ProjectConfig) (gitlab.PipelineTestReport, error) {
+	var pipelineID int
+	if project.TestReportStats.PipelineID != "" {
+		id, err := strconv.Atoi(project.TestReportStats.PipelineID)
+		if err != nil {
+			return gitlab.PipelineTestReport{}, fmt.Errorf("invalid pipeline_id %q for project %s: %w", project.TestReportStats.PipelineID, project.ID, err)
+		}
+		pipelineID = id
+	} else {
+		pipeline, resp, err := git.Pipelines.GetLatestPipeline(project.ID, &gitlab.GetLatestPipelineOptions{}, gitlab.WithContext(ctx))
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			fmt.Printf("No pipelines in project %s, reporting no tests\n", project.ID)
+			return gitlab.PipelineTestReport{}, nil
+		}
+		if err != nil {
+			return gitlab.PipelineTestReport{}, fmt.Errorf("failed to get latest pipeline for project %s: %w", project.ID, err)
+		}
+		pipelineID = pipeline.ID
+	}
+
+	report, resp, err := git.Pipelines.GetPipelineTestReport(project.ID, pipelineID, gitlab.WithContext(ctx))
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		fmt.Printf("No test report for pipeline %d in project %s, reporting no tests\n", pipelineID, project.ID)
+		return gitlab.PipelineTestReport{}, nil
+	}
+	if err != nil {
+		return gitlab.PipelineTestReport{}, fmt.Errorf("failed to get test report of pipeline %d for project %s: %w", pipelineID, project.ID, err)
+	}
+	return *report, nil
+}
@@ -0,0 +1,307 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+var labelPrefix string
+
+// metricTimestamp is the Unix time set on every metric with
+// --metric-timestamp, 0 if unset.
+var metricTimestamp int64
+
+// reservedLabels are set by the Push Gateway and Prometheus and keep their
+// names.
+var reservedLabels = map[string]bool{"job": true, "instance": true}
+
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func init() {
+	scrapeCmd.Flags().StringVar(&labelPrefix, "label-prefix", "", "Prefix prepended to every label name except job and instance, e.g. gl_")
+	scrapeCmd.Flags().Int64Var(&metricTimestamp, "metric-timestamp", 0, "Unix timestamp set on every metric, for backfilling historical data. "+
+		"Discouraged by Prometheus, as explicit timestamps break rate functions and staleness handling, and recent Push Gateway versions reject them. Not allowed with --interval")
+}
+
+// metricTransform post-processes gathered metric families before they are
+// pushed, served or written.
+type metricTransform func([]*dto.MetricFamily) ([]*dto.MetricFamily, error)
+
+// transformGatherer applies transforms in order to the metrics of gatherer.
+type transformGatherer struct {
+	gatherer   prometheus.Gatherer
+	transforms []metricTransform
+}
+
+func (t transformGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := t.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	for _, transform := range t.transforms {
+		if families, err = transform(families); err != nil {
+			return nil, err
+		}
+	}
+	return families, nil
+}
+
+// newTransformGatherer wraps gatherer with the transforms enabled by flags
+// and config.
+func newTransformGatherer(gatherer prometheus.Gatherer, config *Config) prometheus.Gatherer {
+	var transforms []metricTransform
+	if len(config.MetricAliases) > 0 {
+		transforms = append(transforms, aliasMetrics(config.MetricAliases))
+	}
+	if len(config.DisableMetrics) > 0 {
+		transforms = append(transforms, dropMetrics(config.DisableMetrics))
+	}
+	if config.MetricCardinality.enabled() {
+		transforms = append(transforms, enforceCardinality(config))
+	}
+	if len(config.ForceMetricType) > 0 {
+		transforms = append(transforms, forceMetricTypes(config.ForceMetricType))
+	}
+	if len(config.MetricHelpOverrides) > 0 {
+		transforms = append(transforms, overrideHelp(config.MetricHelpOverrides))
+	}
+	if config.LabelSanitizer != "" {
+		transforms = append(transforms, sanitizeLabels(config.LabelSanitizer))
+	}
+	if labelPrefix != "" {
+		transforms = append(transforms, prefixLabels(labelPrefix))
+	}
+	if metricTimestamp != 0 {
+		transforms = append(transforms, setTimestamp(time.Unix(metricTimestamp, 0)))
+	}
+	if len(transforms) == 0 {
+		return gatherer
+	}
+	return transformGatherer{gatherer: gatherer, transforms: transforms}
+}
+
+func validateMetricAliases(aliases map[string]string) error {
+	for name, alias := range aliases {
+		if !model.IsValidMetricName(model.LabelValue(alias)) {
+			return fmt.Errorf("metric alias %q of %s is not a valid metric name", alias, name)
+		}
+		if alias == name {
+			return fmt.Errorf("metric %s is its own alias", name)
+		}
+		if _, ok := aliases[alias]; ok {
+			return fmt.Errorf("metric alias %q of %s is itself an aliased metric", alias, name)
+		}
+	}
+	return nil
+}
+
+// aliasMetrics adds a copy of every metric named in aliases under its alias,
+// for metrics that were renamed and are still pushed under their old name
+// during a migration.
+func aliasMetrics(aliases map[string]string) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		names := map[string]bool{}
+		for _, family := range families {
+			names[family.GetName()] = true
+		}
+
+		for _, family := range families {
+			alias, ok := aliases[family.GetName()]
+			if !ok {
+				continue
+			}
+			if names[alias] {
+				return nil, fmt.Errorf("metric alias %s of %s is already a collected metric", alias, family.GetName())
+			}
+			help := fmt.Sprintf("Alias for %s. Will be removed in a future version.", family.GetName())
+			copied := &dto.MetricFamily{Name: &alias, Help: &help, Type: family.Type, Unit: family.Unit}
+			// The metrics are copied, as later transforms modify them in
+			// place.
+			for _, metric := range family.GetMetric() {
+				copied.Metric = append(copied.Metric, &dto.Metric{
+					Label:       metric.Label,
+					Gauge:       metric.Gauge,
+					Counter:     metric.Counter,
+					Summary:     metric.Summary,
+					Untyped:     metric.Untyped,
+					Histogram:   metric.Histogram,
+					TimestampMs: metric.TimestampMs,
+				})
+			}
+			families = append(families, copied)
+		}
+		return families, nil
+	}
+}
+
+// dropMetrics removes the metrics whose name matches one of the disabled
+// patterns.
+func dropMetrics(disabled []string) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		return slices.DeleteFunc(families, func(family *dto.MetricFamily) bool {
+			return isMetricDisabled(disabled, family.GetName())
+		}), nil
+	}
+}
+
+const (
+	metricTypeGauge   = "gauge"
+	metricTypeCounter = "counter"
+)
+
+func validateForceMetricTypes(types map[string]string) error {
+	for name, metricType := range types {
+		if metricType != metricTypeGauge && metricType != metricTypeCounter {
+			return fmt.Errorf("invalid force_metric_type %q of metric %s, expected %s or %s", metricType, name, metricTypeGauge, metricTypeCounter)
+		}
+	}
+	return nil
+}
+
+// forceMetricTypes turns the gauges named in types into counters. A counter
+// must never decrease, so a series whose value is negative is dropped and
+// one whose value fell below the value in the state file keeps reporting
+// the previous value.
+func forceMetricTypes(types map[string]string) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		for _, family := range families {
+			if types[family.GetName()] != metricTypeCounter || family.GetType() != dto.MetricType_GAUGE {
+				continue
+			}
+			family.Type = dto.MetricType_COUNTER.Enum()
+			family.Metric = slices.DeleteFunc(family.Metric, func(metric *dto.Metric) bool {
+				series := family.GetName() + formatLabels(metric.GetLabel())
+				value := metric.GetGauge().GetValue()
+				if value < 0 {
+					warnf("dropping %s, a counter cannot be negative: %v", series, value)
+					return true
+				}
+				if state != nil {
+					reported, ok := state.recordCounterValue(series, value)
+					if !ok {
+						warnf("%s decreased from %v to %v, keeping the previous counter value", series, reported, value)
+					}
+					value = reported
+				}
+				metric.Gauge = nil
+				metric.Counter = &dto.Counter{Value: &value}
+				return false
+			})
+		}
+		return families, nil
+	}
+}
+
+func validateLabelPrefix() error {
+	if labelPrefix == "" {
+		return nil
+	}
+	if !labelNameRegexp.MatchString(labelPrefix) {
+		return fmt.Errorf("invalid --label-prefix %q: label names may only contain letters, digits and underscores and must not start with a digit", labelPrefix)
+	}
+	if strings.HasPrefix(labelPrefix, "__") {
+		return fmt.Errorf("invalid --label-prefix %q: label names starting with __ are reserved", labelPrefix)
+	}
+	return nil
+}
+
+// prefixLabels prepends prefix to the label names. The label pairs are
+// shared with the collectors that wrote them and are therefore replaced
+// instead of renamed in place.
+func prefixLabels(prefix string) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				labels := make([]*dto.LabelPair, 0, len(metric.GetLabel()))
+				for _, label := range metric.GetLabel() {
+					if reservedLabels[label.GetName()] {
+						labels = append(labels, label)
+						continue
+					}
+					name := prefix + label.GetName()
+					if !labelNameRegexp.MatchString(name) {
+						return nil, fmt.Errorf("label %s of metric %s is not a valid label name", name, family.GetName())
+					}
+					labels = append(labels, &dto.LabelPair{Name: &name, Value: label.Value})
+				}
+				metric.Label = labels
+			}
+		}
+		return families, nil
+	}
+}
+
+// validateMetricTimestamp rejects --metric-timestamp in daemon mode, where
+// every scrape would report the same point in time.
+func validateMetricTimestamp() error {
+	if metricTimestamp < 0 {
+		return fmt.Errorf("invalid --metric-timestamp %d: must be a Unix timestamp", metricTimestamp)
+	}
+	if metricTimestamp != 0 && interval > 0 {
+		return errors.New("--metric-timestamp cannot be used with --interval")
+	}
+	return nil
+}
+
+// setTimestamp sets the timestamp of every metric to at, instead of the time
+// Prometheus scrapes or receives them.
+func setTimestamp(at time.Time) metricTransform {
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		timestampMs := at.UnixMilli()
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				metric.TimestampMs = &timestampMs
+			}
+		}
+		return families, nil
+	}
+}
+
+func validateHelpOverrides(overrides map[string]string) error {
+	for pattern := range overrides {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid metric_help_overrides pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// overrideHelp replaces the help text of the metrics named in overrides. An
+// exact name takes precedence over globs, of which the first in
+// lexicographic order wins.
+func overrideHelp(overrides map[string]string) metricTransform {
+	patterns := slices.Sorted(maps.Keys(overrides))
+	lookup := func(name string) (string, bool) {
+		if help, ok := overrides[name]; ok {
+			return help, true
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, name); matched {
+				return overrides[pattern], true
+			}
+		}
+		return "", false
+	}
+
+	return func(families []*dto.MetricFamily) ([]*dto.MetricFamily, error) {
+		for _, family := range families {
+			if help, ok := lookup(family.GetName()); ok {
+				family.Help = &help
+			}
+		}
+		return families, nil
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+const (
+	defaultUserActivityTopN       = 10
+	defaultUserActivityWithinDays = 30
+	defaultUserActivityMaxMembers = 200
+)
+
+func (c *UserActivityConfig) topN() int {
+	if c.TopN <= 0 {
+		return defaultUserActivityTopN
+	}
+	return c.TopN
+}
+
+func (c *UserActivityConfig) withinDays() int {
+	if c.WithinDays <= 0 {
+		return defaultUserActivityWithinDays
+	}
+	return c.WithinDays
+}
+
+func (c *UserActivityConfig) maxMembers() int {
+	if c.MaxMembers <= 0 {
+		return defaultUserActivityMaxMembers
+	}
+	return c.MaxMembers
+}
+
+type UserActivity struct {
+	Username string
+	Events   int
+}
+
+// getUserActivityStats counts the contribution events in the projects of the
+// group of every member of the group, including inherited members, within
+// the last within_days days and returns the top_n most active members, most
+// active first. The events API filters by day, so events of the whole first
+// day are counted. At most max_members members are checked. Members without
+// events, and members whose events the token may not read, are left out.
+func getUserActivityStats(ctx context.Context, git *gitlab.Client, group GroupConfig, now time.Time) ([]UserActivity, error) {
+	config := group.UserActivityStats
+	after := gitlab.ISOTime(now.AddDate(0, 0, -config.withinDays()-1))
+
+	projects, err := listGroupProjects(ctx, git, group)
+	if err != nil {
+		return nil, err
+	}
+	projectIDs := map[int]bool{}
+	for _, project := range projects {
+		projectIDs[project.ID] = true
+	}
+
+	memberOptions := &gitlab.ListGroupMembersOptions{
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+	var members []*gitlab.GroupMember
+	for {
+		page, resp, err := git.Groups.ListAllGroupMembers(group.ID, memberOptions, gitlab.WithContext(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members for group %s: %w", group.ID, err)
+		}
+		members = append(members, page...)
+
+		if limit := config.maxMembers(); len(members) >= limit {
+			if len(members) > limit || resp.NextPage != 0 {
+				warnf("group %s has more than %d members, only the events of the first %d are counted", group.ID, limit, limit)
+			}
+			members = members[:limit]
+			break
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		memberOptions.Page = resp.NextPage
+	}
+
+	var activity []UserActivity
+	for _, member := range members {
+		events, resp, err := countUserEvents(ctx, git, member.ID, after, projectIDs)
+		if isFeatureUnavailable(resp, err) {
+			fmt.Printf("Events of user %s are not visible, skipping them\n", member.Username)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events of user %s: %w", member.Username, err)
+		}
+		if events > 0 {
+			activity = append(activity, UserActivity{Username: member.Username, Events: events})
+		}
+	}
+
+	slices.SortFunc(activity, func(a, b UserActivity) int {
+		return cmp.Or(cmp.Compare(b.Events, a.Events), cmp.Compare(a.Username, b.Username))
+	})
+	return activity[:min(config.topN(), len(activity))], nil
+}
+
+// countUserEvents counts the contribution events of the user in the projects
+// with the IDs in projectIDs.
+func countUserEvents(ctx context.Context, git *gitlab.Client, userID int, after gitlab.ISOTime, projectIDs map[int]bool) (int, *gitlab.Response, error) {
+	options := &gitlab.ListContributionEventsOptions{
+		After: &after,
+		ListOptions: gitlab.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+	}
+
+	count := 0
+	for {
+		events, resp, err := git.Users.ListUserContributionEvents(userID, options, gitlab.WithContext(ctx))
+		if err != nil {
+			return 0, resp, err
+		}
+		for _, event := range events {
+			if projectIDs[event.ProjectID] {
+				count++
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return count, resp, nil
+		}
+		options.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+var streamMetrics bool
+
+func init() {
+	scrapeCmd.Flags().BoolVar(&streamMetrics, "stream-metrics", false, "Stream the metrics of every scrape as JSON to WebSocket clients on /ws/metrics of the --listen-address server")
+}
+
+// websocketClientBuffer is the number of scrapes a client may fall behind
+// before further scrapes are dropped for it.
+const websocketClientBuffer = 2
+
+const (
+	// websocketWriteTimeout bounds every write, so that a client that stops
+	// reading cannot hold on to the connection.
+	websocketWriteTimeout = 10 * time.Second
+	// websocketPingInterval is how often the server pings the client, so
+	// that a client that went away fails the next write.
+	websocketPingInterval = 30 * time.Second
+)
+
+// maxWebsocketMessage limits the messages accepted from clients, which have
+// no reason to send any.
+const maxWebsocketMessage = 1 << 16
+
+type metricMessage struct {
+	Metric string            `json:"metric"`
+	Labels map[string]string `json:"labels"`
+	// Value is null for NaN values, which JSON cannot represent.
+	Value     *float64  `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// metricsHub sends the metrics of every scrape to the connected WebSocket
+// clients without ever blocking the scrape loop on a slow client.
+type metricsHub struct {
+	mu      sync.Mutex
+	clients map[*websocketClient]struct{}
+	latest  []byte
+}
+
+type websocketClient struct {
+	remote string
+	send   chan []byte
+}
+
+func newMetricsHub() *metricsHub {
+	return &metricsHub{clients: map[*websocketClient]struct{}{}}
+}
+
+// publish sends values to every client. Clients that have not caught up with
+// the previous scrapes miss this one.
+func (h *metricsHub) publish(values []metricValue, at time.Time) error {
+	messages := make([]metricMessage, 0, len(values))
+	for _, value := range values {
+		labels := map[string]string{}
+		for _, label := range value.Labels {
+			labels[label.GetName()] = label.GetValue()
+		}
+		message := metricMessage{Metric: value.Name, Labels: labels, Timestamp: at}
+		if !math.IsNaN(value.Value) && !math.IsInf(value.Value, 0) {
+			message.Value = &value.Value
+		}
+		messages = append(messages, message)
+	}
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest = data
+	for client := range h.clients {
+		select {
+		case client.send <- data:
+		default:
+			warnf("WebSocket client %s is too slow, dropping metrics of this scrape", client.remote)
+		}
+	}
+	return nil
+}
+
+func (h *metricsHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server := websocket.Server{
+		Handshake: func(_ *websocket.Config, r *http.Request) error {
+			if !sameOrigin(r) {
+				return errors.New("cross-origin WebSocket requests are not allowed")
+			}
+			return nil
+		},
+		Handler: h.serveClient,
+	}
+	server.ServeHTTP(w, r)
+}
+
+func (h *metricsHub) serveClient(conn *websocket.Conn) {
+	defer conn.Close()
+	conn.MaxPayloadBytes = maxWebsocketMessage
+
+	client := &websocketClient{remote: conn.Request().RemoteAddr, send: make(chan []byte, websocketClientBuffer)}
+	h.mu.Lock()
+	if h.latest != nil {
+		client.send <- h.latest
+	}
+	h.clients[client] = struct{}{}
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		delete(h.clients, client)
+		h.mu.Unlock()
+	}()
+
+	// Clients have nothing to send. Reading answers their pings and notices
+	// when they close the connection or send an oversized message.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var message []byte
+			if err := websocket.Message.Receive(conn, &message); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(websocketPingInterval)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			conn.PayloadType = websocket.PingFrame
+			_, err = conn.Write(nil)
+		case data := <-client.send:
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			err = websocket.Message.Send(conn, string(data))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// sameOrigin reports whether the request has no Origin header or one whose
+// host is the host of the request, so that pages of other sites cannot read
+// the metrics through the browser of a visitor.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
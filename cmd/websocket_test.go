@@ -0,0 +1,76 @@
+/*
+Copyright © 2025 Alexander Padberg <undefinedhuman>
+*/
+package cmd
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"golang.org/x/net/websocket"
+)
+
+func dialMetricsHub(t *testing.T, hub *metricsHub, origin string) (*websocket.Conn, error) {
+	server := httptest.NewServer(hub)
+	t.Cleanup(server.Close)
+	if origin == "" {
+		origin = server.URL
+	}
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", origin)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, err
+}
+
+func TestMetricsHubSendsLatestScrape(t *testing.T) {
+	hub := newMetricsHub()
+	name, value := "group_id", "1"
+	values := []metricValue{{
+		Name:   "gitlab_group_members_count",
+		Labels: []*dto.LabelPair{{Name: &name, Value: &value}},
+		Value:  3,
+	}}
+	if err := hub.publish(values, time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := dialMetricsHub(t, hub, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var messages []metricMessage
+	if err := websocket.JSON.Receive(conn, &messages); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Metric != "gitlab_group_members_count" || messages[0].Labels["group_id"] != "1" ||
+		messages[0].Value == nil || *messages[0].Value != 3 {
+		t.Errorf("received %+v, want gitlab_group_members_count{group_id=\"1\"} 3", messages)
+	}
+}
+
+func TestMetricsHubRejectsCrossOriginRequests(t *testing.T) {
+	if _, err := dialMetricsHub(t, newMetricsHub(), "http://example.com"); err == nil {
+		t.Error("cross-origin WebSocket request was accepted")
+	}
+}
+
+func TestMetricsHubClosesOnOversizedMessage(t *testing.T) {
+	conn, err := dialMetricsHub(t, newMetricsHub(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := websocket.Message.Send(conn, strings.Repeat("x", maxWebsocketMessage+1)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message string
+	err = websocket.Message.Receive(conn, &message)
+	if err == nil || strings.Contains(err.Error(), "timeout") {
+		t.Errorf("connection is still open after an oversized message: %v", err)
+	}
+}